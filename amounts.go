@@ -0,0 +1,240 @@
+package iso8583
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AccountType is the 2 digit account type used in the processing code
+// and additional amounts.
+type AccountType string
+
+// List of common account types.
+const (
+	AccountDefault  AccountType = "00"
+	AccountSavings  AccountType = "10"
+	AccountChecking AccountType = "20"
+	AccountCredit   AccountType = "30"
+)
+
+// AmountType is the 2 digit type of an additional amount.
+type AmountType string
+
+// List of common additional amount types.
+const (
+	AmountLedgerBalance    AmountType = "01"
+	AmountAvailableBalance AmountType = "02"
+	AmountCashBack         AmountType = "40"
+	AmountOriginal         AmountType = "57"
+)
+
+// maxAdditionalAmounts is the number of 20 character blocks field 54 can hold.
+const maxAdditionalAmounts = 6
+
+// AdditionalAmount is one 20 character block of field 54.
+type AdditionalAmount struct {
+	AccountType  AccountType
+	AmountType   AmountType
+	CurrencyCode string // n 3
+
+	// Amount in minor units, negative for debit (D) amounts.
+	Amount int64
+}
+
+// String formats the amount as a 20 character block.
+func (a AdditionalAmount) String() string {
+	sign, amount := "C", a.Amount
+	if amount < 0 {
+		sign, amount = "D", -amount
+	}
+	return fmt.Sprintf("%s%s%s%s%012d", string(a.AccountType), string(a.AmountType), a.CurrencyCode, sign, amount)
+}
+
+// Validate checks the components of the amount.
+func (a AdditionalAmount) Validate() error {
+	if len(a.AccountType) != 2 || !isNumeric(string(a.AccountType)) {
+		return fmt.Errorf("invalid account type %q", string(a.AccountType))
+	}
+	if len(a.AmountType) != 2 || !isNumeric(string(a.AmountType)) {
+		return fmt.Errorf("invalid amount type %q", a.AmountType)
+	}
+	if len(a.CurrencyCode) != 3 || !isNumeric(a.CurrencyCode) {
+		return fmt.Errorf("invalid currency code %q", a.CurrencyCode)
+	}
+	if a.Amount > 999999999999 || a.Amount < -999999999999 {
+		return fmt.Errorf("amount %d does not fit in 12 digits", a.Amount)
+	}
+	return nil
+}
+
+// parseAdditionalAmount parses a 20 character block.
+func parseAdditionalAmount(block string) (AdditionalAmount, error) {
+	if len(block) != 20 {
+		return AdditionalAmount{}, fmt.Errorf("additional amount must have 20 characters, got %d", len(block))
+	}
+
+	amount, err := strconv.ParseInt(block[8:], 10, 64)
+	if err != nil || !isNumeric(block[8:]) {
+		return AdditionalAmount{}, fmt.Errorf("invalid amount %q", block[8:])
+	}
+
+	switch block[7] {
+	case 'C':
+	case 'D':
+		amount = -amount
+	default:
+		return AdditionalAmount{}, fmt.Errorf("invalid amount sign %q", block[7])
+	}
+
+	a := AdditionalAmount{
+		AccountType:  AccountType(block[0:2]),
+		AmountType:   AmountType(block[2:4]),
+		CurrencyCode: block[4:7],
+		Amount:       amount,
+	}
+
+	return a, a.Validate()
+}
+
+// AdditionalAmounts is the content of field 54, up to six amounts.
+type AdditionalAmounts []AdditionalAmount
+
+// ParseAdditionalAmounts parses the value of field 54.
+func ParseAdditionalAmounts(value string) (AdditionalAmounts, error) {
+	if len(value)%20 != 0 || len(value) > 20*maxAdditionalAmounts {
+		return nil, fmt.Errorf("additional amounts must be up to %d blocks of 20 characters, got %d characters", maxAdditionalAmounts, len(value))
+	}
+
+	var amounts AdditionalAmounts
+	for i := 0; i < len(value); i += 20 {
+		a, err := parseAdditionalAmount(value[i : i+20])
+		if err != nil {
+			return nil, fmt.Errorf("additional amount %d: %v", i/20+1, err)
+		}
+		amounts = append(amounts, a)
+	}
+
+	return amounts, nil
+}
+
+// String formats the amounts as the value of field 54.
+func (a AdditionalAmounts) String() string {
+	var value strings.Builder
+	for _, amount := range a {
+		value.WriteString(amount.String())
+	}
+	return value.String()
+}
+
+// Add appends an amount, replacing an existing amount of the same
+// account and amount type.
+func (a *AdditionalAmounts) Add(amount AdditionalAmount) error {
+	if err := amount.Validate(); err != nil {
+		return err
+	}
+
+	for i, existing := range *a {
+		if existing.AccountType == amount.AccountType && existing.AmountType == amount.AmountType {
+			(*a)[i] = amount
+			return nil
+		}
+	}
+
+	if len(*a) >= maxAdditionalAmounts {
+		return fmt.Errorf("field 54 holds at most %d additional amounts", maxAdditionalAmounts)
+	}
+
+	*a = append(*a, amount)
+
+	return nil
+}
+
+// Remove deletes the amount of the given account and amount type,
+// reporting whether it was present.
+func (a *AdditionalAmounts) Remove(accountType AccountType, amountType AmountType) bool {
+	for i, existing := range *a {
+		if existing.AccountType == accountType && existing.AmountType == amountType {
+			*a = append((*a)[:i], (*a)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the first amount of the given amount type.
+func (a AdditionalAmounts) Find(amountType AmountType) (AdditionalAmount, bool) {
+	for _, amount := range a {
+		if amount.AmountType == amountType {
+			return amount, true
+		}
+	}
+	return AdditionalAmount{}, false
+}
+
+// SetAdditionalAmounts sets field 54 from typed amounts.
+func (mb *MessageBuilder) SetAdditionalAmounts(amounts AdditionalAmounts) *MessageBuilder {
+	return mb.AddField(54, amounts.String())
+}
+
+// AdditionalAmounts returns the parsed field 54 of the message.
+func (m *Parser) AdditionalAmounts() (AdditionalAmounts, error) {
+	value, ok := m.Fields[54]
+	if !ok {
+		return nil, nil
+	}
+	return ParseAdditionalAmounts(value)
+}
+
+// AdditionalAmountsCodec decomposes field 54 into its 20 character
+// amount blocks, keyed by position from 1, e.g. "54.2".
+type AdditionalAmountsCodec struct{}
+
+// Decode splits the value of field 54 into amount blocks.
+func (AdditionalAmountsCodec) Decode(value string) (map[string]string, error) {
+	amounts, err := ParseAdditionalAmounts(value)
+	if err != nil {
+		return nil, err
+	}
+
+	subfields := make(map[string]string, len(amounts))
+	for i, amount := range amounts {
+		subfields[strconv.Itoa(i+1)] = amount.String()
+	}
+
+	return subfields, nil
+}
+
+// Encode composes the value of field 54 from amount blocks.
+func (AdditionalAmountsCodec) Encode(subfields map[string]string) (string, error) {
+	positions := make([]int, 0, len(subfields))
+	for key := range subfields {
+		position, err := strconv.Atoi(key)
+		if err != nil || position < 1 || position > maxAdditionalAmounts {
+			return "", fmt.Errorf("invalid additional amount position %q", key)
+		}
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+
+	var amounts AdditionalAmounts
+	for _, position := range positions {
+		amount, err := parseAdditionalAmount(subfields[strconv.Itoa(position)])
+		if err != nil {
+			return "", fmt.Errorf("additional amount %d: %v", position, err)
+		}
+		amounts = append(amounts, amount)
+	}
+
+	return amounts.String(), nil
+}
+
+// Element returns the definition of an amount block.
+func (AdditionalAmountsCodec) Element(tag string) (Element, bool) {
+	position, err := strconv.Atoi(tag)
+	if err != nil || position < 1 || position > maxAdditionalAmounts {
+		return Element{}, false
+	}
+	return Element{ContentType: "an", Label: "Additional amount " + tag, LenType: Fixed, MaxLen: 20}, true
+}
@@ -0,0 +1,142 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestParseAdditionalAmounts(t *testing.T) {
+	amounts, err := ParseAdditionalAmounts("1001840C000000015000" + "1002840D000000002500")
+	if err != nil {
+		t.Fatalf("ParseAdditionalAmounts() error = %v", err)
+	}
+
+	expected := AdditionalAmounts{
+		{AccountType: AccountSavings, AmountType: AmountLedgerBalance, CurrencyCode: "840", Amount: 15000},
+		{AccountType: AccountSavings, AmountType: AmountAvailableBalance, CurrencyCode: "840", Amount: -2500},
+	}
+	if len(amounts) != len(expected) || amounts[0] != expected[0] || amounts[1] != expected[1] {
+		t.Errorf("Expected %+v, got %+v", expected, amounts)
+	}
+
+	if amounts.String() != "1001840C000000015000"+"1002840D000000002500" {
+		t.Errorf("Unexpected formatting %s", amounts.String())
+	}
+
+	for _, invalid := range []string{"1001840C00000001500", "1001840X000000015000", "1001840C00000001500A"} {
+		if _, err := ParseAdditionalAmounts(invalid); err == nil {
+			t.Errorf("ParseAdditionalAmounts(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestAdditionalAmountsAddRemove(t *testing.T) {
+	var amounts AdditionalAmounts
+
+	balance := AdditionalAmount{AccountType: AccountChecking, AmountType: AmountAvailableBalance, CurrencyCode: "986", Amount: 100}
+	if err := amounts.Add(balance); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	balance.Amount = 200
+	if err := amounts.Add(balance); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if len(amounts) != 1 || amounts[0].Amount != 200 {
+		t.Errorf("Expected the amount to be replaced, got %+v", amounts)
+	}
+
+	if err := amounts.Add(AdditionalAmount{AccountType: "1", AmountType: "02", CurrencyCode: "986"}); err == nil {
+		t.Errorf("Expected error for invalid account type")
+	}
+
+	for i := 0; i < 5; i++ {
+		amount := AdditionalAmount{AccountType: AccountDefault, AmountType: AmountType("0" + string(rune('3'+i))), CurrencyCode: "986"}
+		if err := amounts.Add(amount); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if err := amounts.Add(AdditionalAmount{AccountType: AccountDefault, AmountType: AmountCashBack, CurrencyCode: "986"}); err == nil {
+		t.Errorf("Expected error adding a seventh amount")
+	}
+
+	if !amounts.Remove(AccountChecking, AmountAvailableBalance) || amounts.Remove(AccountChecking, AmountAvailableBalance) {
+		t.Errorf("Expected the amount to be removed once")
+	}
+	if _, ok := amounts.Find(AmountAvailableBalance); ok {
+		t.Errorf("Expected the removed amount not to be found")
+	}
+}
+
+func TestField54(t *testing.T) {
+	amounts := AdditionalAmounts{
+		{AccountType: AccountDefault, AmountType: AmountOriginal, CurrencyCode: "840", Amount: 10000},
+	}
+
+	msg := NewISO()
+	msg.SetMTI("0110")
+	msg.AddField(39, "10")
+	msg.SetAdditionalAmounts(amounts)
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	decoded, err := parsed.AdditionalAmounts()
+	if err != nil {
+		t.Fatalf("AdditionalAmounts() error = %v", err)
+	}
+
+	original, ok := decoded.Find(AmountOriginal)
+	if !ok || original.Amount != 10000 {
+		t.Errorf("Expected original amount 10000, got %+v", decoded)
+	}
+
+	if block, _ := parsed.Subfield("54.1"); block != "0057840C000000010000" {
+		t.Errorf("Subfield 54.1: expected 0057840C000000010000, got %q", block)
+	}
+
+	msg = NewISO().SetMTI("0110")
+	msg.SetSubfield("54.1", "0057840C000000010000")
+	msg.SetSubfield("54.2", "0040840D000000000500")
+	if raw, err = msg.Build(); err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if expected := "0400057840C0000000100000040840D000000000500"; raw[len(raw)-len(expected):] != expected {
+		t.Errorf("Expected field 54 = %s, got %s", expected, raw)
+	}
+}
+
+func TestField54NonStandardRoundTrip(t *testing.T) {
+	value := "PRIVATE BALANCE 000100"
+
+	msg := NewISO()
+	msg.SetMTI("0110")
+	msg.AddField(39, "00")
+	msg.AddField(54, value)
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if parsed.Fields[54] != value {
+		t.Errorf("Field 54: expected %s, got %s", value, parsed.Fields[54])
+	}
+	if _, ok := parsed.Subfield("54.1"); ok {
+		t.Errorf("Expected no amount blocks for non-standard field 54")
+	}
+	if _, err := parsed.AdditionalAmounts(); err == nil {
+		t.Errorf("Expected error parsing non-standard field 54 as amounts")
+	}
+}
@@ -10,17 +10,33 @@ import (
 // MessageBuilder provides methods to construct
 // an ISO 8583 message.
 type MessageBuilder struct {
-	MTI    string
-	Fields map[int]string
+	MTI       string
+	Fields    map[int]string
+	Subfields map[string]string
+
+	spec *Spec
 }
 
 // NewMessage initializes a new MessageBuilder with an MTI.
 func NewISO() *MessageBuilder {
+	return NewISOWithSpec(DefaultSpec)
+}
+
+// NewISOWithSpec initializes a new MessageBuilder that builds
+// fields according to the given spec.
+func NewISOWithSpec(spec *Spec) *MessageBuilder {
 	return &MessageBuilder{
-		Fields: make(map[int]string),
+		Fields:    make(map[int]string),
+		Subfields: make(map[string]string),
+		spec:      spec,
 	}
 }
 
+// Spec returns the spec used by the builder.
+func (mb *MessageBuilder) Spec() *Spec {
+	return specOrDefault(mb.spec)
+}
+
 // SetMTI sets the Message Type Indicator (MTI) for the ISO 8583 message.
 func (mb *MessageBuilder) SetMTI(mti string) *MessageBuilder {
 	mb.MTI = mti
@@ -39,17 +55,31 @@ func (mb *MessageBuilder) Build() (string, error) {
 		return "", fmt.Errorf("MTI is required")
 	}
 
-	// Initialize bitmaps
-	primaryBitmap := make([]int, 64)
-	secondaryBitmap := make([]int, 64)
-	needSecondaryBitmap := false
-
 	// Initialize the field builder
 	var fields strings.Builder
+	var bitmapFields []int
 
-	// Sort the field numbers
-	fieldNumbers := make([]int, 0, len(mb.Fields))
+	spec := mb.Spec()
+
+	// Collect the field numbers, including fields composed from subfields
+	present := make(map[int]bool, len(mb.Fields))
 	for fieldNum := range mb.Fields {
+		present[fieldNum] = true
+	}
+	for path := range mb.Subfields {
+		if !strings.Contains(path, ".") {
+			return "", fmt.Errorf("subfield path %s has no subfield", path)
+		}
+		if _, ok := spec.SubElement(path); !ok {
+			return "", fmt.Errorf("unsupported subfield %s", path)
+		}
+		fieldNum, _ := subfieldNumber(path)
+		present[fieldNum] = true
+	}
+
+	// Sort the field numbers
+	fieldNumbers := make([]int, 0, len(present))
+	for fieldNum := range present {
 		fieldNumbers = append(fieldNumbers, fieldNum)
 	}
 	sort.Ints(fieldNumbers)
@@ -58,21 +88,26 @@ func (mb *MessageBuilder) Build() (string, error) {
 	for _, fieldNum := range fieldNumbers {
 		value := mb.Fields[fieldNum]
 
-		if fieldNum >= 1 && fieldNum <= 64 {
-			primaryBitmap[fieldNum-1] = 1
-		} else if fieldNum > 64 && fieldNum <= 128 {
-			secondaryBitmap[fieldNum-65] = 1
-			needSecondaryBitmap = true
-		} else {
+		if fieldNum < 1 || fieldNum > 128 {
 			continue // Skip unsupported field numbers
 		}
+		bitmapFields = append(bitmapFields, fieldNum)
 
-		elem, exists := dataElem[fieldNum]
+		elem, exists := spec.Element(fieldNum)
 		if !exists {
 			return "", fmt.Errorf("unsupported field %d", fieldNum)
 		}
 
-		fieldValue, err := constructFieldValue(fieldNum, value, elem)
+		path := strconv.Itoa(fieldNum)
+		if elem.composite() && hasNestedSubfields(mb.Subfields, path) {
+			composed, err := composeElement(path, elem, mb.Subfields)
+			if err != nil {
+				return "", fmt.Errorf("error composing field %d: %v", fieldNum, err)
+			}
+			value = composed
+		}
+
+		fieldValue, err := constructFieldValue(value, elem)
 		if err != nil {
 			return "", fmt.Errorf("error constructing field %d: %v", fieldNum, err)
 		}
@@ -80,22 +115,35 @@ func (mb *MessageBuilder) Build() (string, error) {
 		fields.WriteString(fieldValue)
 	}
 
-	// If a secondary bitmap is needed, set the first bit of the primary bitmap
-	if needSecondaryBitmap {
-		primaryBitmap[0] = 1
+	// Combine MTI, bitmaps, and fields
+	finalMessage := mb.MTI + encodeBitmap(bitmapFields) + fields.String()
+
+	return finalMessage, nil
+}
+
+// encodeBitmap returns the hexadecimal bitmap of the given field numbers,
+// adding a secondary bitmap when fields above 64 are present.
+func encodeBitmap(fieldNums []int) string {
+	primaryBitmap := make([]int, 64)
+	secondaryBitmap := make([]int, 64)
+	needSecondaryBitmap := false
+
+	for _, fieldNum := range fieldNums {
+		if fieldNum >= 1 && fieldNum <= 64 {
+			primaryBitmap[fieldNum-1] = 1
+		} else if fieldNum > 64 && fieldNum <= 128 {
+			secondaryBitmap[fieldNum-65] = 1
+			needSecondaryBitmap = true
+		}
 	}
 
-	// Convert bitmaps to hexadecimal strings
-	hexPrimaryBitmap := bitmapToHex(primaryBitmap)
-	var hexSecondaryBitmap string
+	// If a secondary bitmap is needed, set the first bit of the primary bitmap
 	if needSecondaryBitmap {
-		hexSecondaryBitmap = bitmapToHex(secondaryBitmap)
+		primaryBitmap[0] = 1
+		return bitmapToHex(primaryBitmap) + bitmapToHex(secondaryBitmap)
 	}
 
-	// Combine MTI, bitmaps, and fields
-	finalMessage := mb.MTI + hexPrimaryBitmap + hexSecondaryBitmap + fields.String()
-
-	return finalMessage, nil
+	return bitmapToHex(primaryBitmap)
 }
 
 // bitmapToHex converts a binary bitmap slice to a hexadecimal string.
@@ -121,29 +169,59 @@ func binaryStringToHex(binary string) string {
 }
 
 // constructFieldValue formats the field value based on ISO 8583 standards (fixed, LLVAR, LLLVAR).
-func constructFieldValue(fieldNum int, value string, elem Element) (string, error) {
+func constructFieldValue(value string, elem Element) (string, error) {
 	var fieldBuilder strings.Builder
 
 	switch elem.LenType {
 	case Fixed:
+		// Truncating a signed amount would change it
+		if elem.ContentType == "x+n" && len(value) > elem.MaxLen {
+			return "", fmt.Errorf("signed amount %s is longer than %d", value, elem.MaxLen)
+		}
 		paddedValue := padOrTruncate(value, elem.MaxLen, elem.ContentType)
-		fieldBuilder.WriteString(paddedValue)
+		if err := validateContent(paddedValue, elem.ContentType); err != nil {
+			return "", err
+		}
+		fieldBuilder.WriteString(elem.Encoding.encode(paddedValue))
 
 	case LLVAR:
+		if len(value) > 99 {
+			return "", fmt.Errorf("length %d does not fit the length indicator", len(value))
+		}
+		if err := validateContent(value, elem.ContentType); err != nil {
+			return "", err
+		}
 		lengthIndicator := fmt.Sprintf("%02d", len(value))
-		fieldBuilder.WriteString(lengthIndicator + value)
+		fieldBuilder.WriteString(lengthIndicator + elem.Encoding.encode(value))
 
 	case LLLVAR:
+		if len(value) > 999 {
+			return "", fmt.Errorf("length %d does not fit the length indicator", len(value))
+		}
+		if err := validateContent(value, elem.ContentType); err != nil {
+			return "", err
+		}
 		lengthIndicator := fmt.Sprintf("%03d", len(value))
-		fieldBuilder.WriteString(lengthIndicator + value)
+		fieldBuilder.WriteString(lengthIndicator + elem.Encoding.encode(value))
 
 	default:
-		return "", fmt.Errorf("unsupported length type for field %d", fieldNum)
+		return "", fmt.Errorf("unsupported length type %d", elem.LenType)
 	}
 
 	return fieldBuilder.String(), nil
 }
 
+// validateContent checks values of content types with a strict format.
+func validateContent(value string, contentType string) error {
+	switch contentType {
+	case "x+n":
+		if _, err := ParseSignedAmount(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // padOrTruncate ensures the value fits the specified length for fixed fields.
 func padOrTruncate(value string, length int, contentType string) string {
 	if len(value) > length {
@@ -160,6 +238,11 @@ func padOrTruncate(value string, length int, contentType string) string {
 		return fmt.Sprintf("%-*s", length, value)
 	case "b": // Binary fields are zero-padded on the left
 		return fmt.Sprintf("%0*s", length, value)
+	case "x+n": // Signed amounts keep the C/D sign and zero-pad the digits
+		if value == "" {
+			value = "C"
+		}
+		return value[:1] + fmt.Sprintf("%0*s", length-1, value[1:])
 	default:
 		return value
 	}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"iso8583"
+)
+
+// Config describes the package and message types to generate.
+type Config struct {
+	Package  string
+	Messages []MessageConfig
+}
+
+// MessageConfig describes a message type: its struct name, MTI, the
+// fields it always carries and the fields it may carry.
+type MessageConfig struct {
+	Name     string
+	MTI      string
+	Fields   []int
+	Optional []int
+
+	// Names overrides the struct field names derived from the element labels.
+	Names map[int]string
+}
+
+// goField is a struct field generated for an element.
+type goField struct {
+	Num      int
+	Name     string
+	Label    string
+	Type     string
+	Optional bool
+}
+
+// generate emits the Go source for the configured message types.
+func generate(spec *iso8583.Spec, cfg Config, importPath string) ([]byte, error) {
+	if cfg.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+
+	var body bytes.Buffer
+	usesStrconv := false
+
+	for _, msg := range cfg.Messages {
+		fields, err := messageFields(spec, msg)
+		if err != nil {
+			return nil, fmt.Errorf("message %s: %v", msg.Name, err)
+		}
+
+		for _, f := range fields {
+			if strings.HasSuffix(f.Type, "int64") {
+				usesStrconv = true
+			}
+		}
+
+		writeMessage(&body, msg, fields)
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by iso8583gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", cfg.Package)
+	src.WriteString("import (\n\t\"fmt\"\n")
+	if usesStrconv {
+		src.WriteString("\t\"strconv\"\n")
+	}
+	fmt.Fprintf(&src, "\n\t%q\n)\n", importPath)
+	src.Write(body.Bytes())
+
+	out, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v", err)
+	}
+
+	return out, nil
+}
+
+// messageFields resolves the struct fields of a message from the spec.
+func messageFields(spec *iso8583.Spec, msg MessageConfig) ([]goField, error) {
+	if msg.Name == "" || len(msg.MTI) != 4 {
+		return nil, fmt.Errorf("name and a 4 digit MTI are required")
+	}
+
+	var fields []goField
+	names := make(map[string]bool)
+
+	add := func(fieldNum int, optional bool) error {
+		if fieldNum == 1 || fieldNum == 65 {
+			return fmt.Errorf("field %d is a bitmap", fieldNum)
+		}
+
+		elem, ok := spec.Element(fieldNum)
+		if !ok {
+			return fmt.Errorf("field %d is not defined in the spec", fieldNum)
+		}
+
+		name := msg.Names[fieldNum]
+		if name == "" {
+			name = identifier(elem.Label)
+		}
+		if name == "" || names[name] {
+			name = fmt.Sprintf("%sField%d", name, fieldNum)
+		}
+		names[name] = true
+
+		fields = append(fields, goField{
+			Num:      fieldNum,
+			Name:     name,
+			Label:    elem.Label,
+			Type:     fieldType(elem, optional),
+			Optional: optional,
+		})
+
+		return nil
+	}
+
+	for _, fieldNum := range msg.Fields {
+		if err := add(fieldNum, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, fieldNum := range msg.Optional {
+		if err := add(fieldNum, true); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Num < fields[j].Num })
+
+	return fields, nil
+}
+
+// fieldType maps an element to the Go type of its struct field.
+func fieldType(elem iso8583.Element, optional bool) string {
+	var typ string
+	switch {
+	case elem.ContentType == "n" && elem.LenType == iso8583.Fixed && elem.MaxLen <= 18:
+		typ = "int64"
+	case elem.ContentType == "b":
+		return "[]byte"
+	default:
+		typ = "string"
+	}
+
+	if optional {
+		return "*" + typ
+	}
+	return typ
+}
+
+// identifier converts an element label into an exported Go identifier,
+// e.g. "Amount, transaction" becomes "AmountTransaction".
+func identifier(label string) string {
+	words := strings.FieldsFunc(label, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var id strings.Builder
+	for _, word := range words {
+		id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+
+	if id.Len() > 0 && unicode.IsDigit(rune(id.String()[0])) {
+		return "F" + id.String()
+	}
+	return id.String()
+}
+
+// writeMessage emits the struct, Pack and Unpack methods of a message.
+func writeMessage(w *bytes.Buffer, msg MessageConfig, fields []goField) {
+	fmt.Fprintf(w, "\n// %s is the %s message.\n", msg.Name, msg.MTI)
+	fmt.Fprintf(w, "type %s struct {\n", msg.Name)
+	for _, f := range fields {
+		fmt.Fprintf(w, "\t// %s is field %d, %s.\n", f.Name, f.Num, f.Label)
+		fmt.Fprintf(w, "\t%s %s\n", f.Name, f.Type)
+	}
+	w.WriteString("}\n")
+
+	fmt.Fprintf(w, "\n// Pack creates a %s message builder from m using spec, or the\n", msg.MTI)
+	w.WriteString("// default spec when spec is nil.\n")
+	fmt.Fprintf(w, "func (m *%s) Pack(spec *iso8583.Spec) *iso8583.MessageBuilder {\n", msg.Name)
+	w.WriteString("\tmb := iso8583.NewISOWithSpec(spec)\n")
+	fmt.Fprintf(w, "\tmb.SetMTI(%q)\n", msg.MTI)
+	for _, f := range fields {
+		writePackField(w, f)
+	}
+	w.WriteString("\treturn mb\n}\n")
+
+	fmt.Fprintf(w, "\n// Unpack fills m from a parsed %s message.\n", msg.MTI)
+	fmt.Fprintf(w, "func (m *%s) Unpack(p *iso8583.Parser) error {\n", msg.Name)
+	fmt.Fprintf(w, "\tif p.MTI != %q {\n", msg.MTI)
+	fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"unexpected MTI %%s, expected %s\", p.MTI)\n\t}\n", msg.MTI)
+	for _, f := range fields {
+		writeUnpackField(w, f)
+	}
+	w.WriteString("\treturn nil\n}\n")
+}
+
+// writePackField emits the statement adding a struct field to the builder.
+func writePackField(w *bytes.Buffer, f goField) {
+	switch f.Type {
+	case "string":
+		fmt.Fprintf(w, "\tmb.AddField(%d, m.%s)\n", f.Num, f.Name)
+	case "int64":
+		fmt.Fprintf(w, "\tmb.AddField(%d, strconv.FormatInt(m.%s, 10))\n", f.Num, f.Name)
+	case "[]byte":
+		if f.Optional {
+			fmt.Fprintf(w, "\tif m.%s != nil {\n\t\tmb.AddField(%d, string(m.%s))\n\t}\n", f.Name, f.Num, f.Name)
+		} else {
+			fmt.Fprintf(w, "\tmb.AddField(%d, string(m.%s))\n", f.Num, f.Name)
+		}
+	case "*string":
+		fmt.Fprintf(w, "\tif m.%s != nil {\n\t\tmb.AddField(%d, *m.%s)\n\t}\n", f.Name, f.Num, f.Name)
+	case "*int64":
+		fmt.Fprintf(w, "\tif m.%s != nil {\n\t\tmb.AddField(%d, strconv.FormatInt(*m.%s, 10))\n\t}\n", f.Name, f.Num, f.Name)
+	}
+}
+
+// writeUnpackField emits the statements reading a struct field from the parser.
+func writeUnpackField(w *bytes.Buffer, f goField) {
+	fmt.Fprintf(w, "\tif v, ok := p.Fields[%d]; ok {\n", f.Num)
+
+	switch f.Type {
+	case "string":
+		fmt.Fprintf(w, "\t\tm.%s = v\n", f.Name)
+	case "*string":
+		fmt.Fprintf(w, "\t\tm.%s = &v\n", f.Name)
+	case "[]byte":
+		fmt.Fprintf(w, "\t\tm.%s = []byte(v)\n", f.Name)
+	case "int64", "*int64":
+		w.WriteString("\t\tn, err := strconv.ParseInt(v, 10, 64)\n")
+		w.WriteString("\t\tif err != nil {\n")
+		fmt.Fprintf(w, "\t\t\treturn fmt.Errorf(\"field %d: %%v\", err)\n\t\t}\n", f.Num)
+		if f.Optional {
+			fmt.Fprintf(w, "\t\tm.%s = &n\n", f.Name)
+		} else {
+			fmt.Fprintf(w, "\t\tm.%s = n\n", f.Name)
+		}
+	}
+
+	if f.Optional {
+		w.WriteString("\t}\n")
+	} else {
+		fmt.Fprintf(w, "\t} else {\n\t\treturn fmt.Errorf(\"missing field %d\")\n\t}\n", f.Num)
+	}
+}
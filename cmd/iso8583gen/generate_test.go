@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"iso8583"
+	"iso8583/examples/messages"
+)
+
+func TestGenerateMatchesExample(t *testing.T) {
+	raw, err := os.ReadFile("../../examples/messages/messages.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(iso8583.DefaultSpec, cfg, "iso8583")
+	if err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	expected, err := os.ReadFile("../../examples/messages/messages_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(src) != string(expected) {
+		t.Errorf("generated code differs from examples/messages/messages_gen.go, run go generate")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := map[string]Config{
+		"no package":      {Messages: []MessageConfig{{Name: "A", MTI: "0200", Fields: []int{2}}}},
+		"invalid MTI":     {Package: "p", Messages: []MessageConfig{{Name: "A", MTI: "20", Fields: []int{2}}}},
+		"undefined field": {Package: "p", Messages: []MessageConfig{{Name: "A", MTI: "0200", Fields: []int{129}}}},
+		"bitmap field":    {Package: "p", Messages: []MessageConfig{{Name: "A", MTI: "0200", Fields: []int{1}}}},
+	}
+
+	for name, cfg := range tests {
+		if _, err := generate(iso8583.DefaultSpec, cfg, "iso8583"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"Amount, transaction":              "AmountTransaction",
+		"Primary account number (PAN)":     "PrimaryAccountNumberPAN",
+		"Time, local transaction (hhmmss)": "TimeLocalTransactionHhmmss",
+		"3DS data":                         "F3DSData",
+	}
+
+	for label, expected := range tests {
+		if id := identifier(label); id != expected {
+			t.Errorf("identifier(%q): expected %s, got %s", label, expected, id)
+		}
+	}
+}
+
+func TestGeneratedRoundTrip(t *testing.T) {
+	expiry := int64(2402)
+	request := messages.FinancialRequest{
+		PAN:                                "4000001234567890",
+		AmountTransaction:                  6000,
+		TransmissionDateTime:               209123456,
+		SystemTraceAuditNumber:             1,
+		DateExpiration:                     &expiry,
+		CardAcceptorTerminalIdentification: "TERM1234",
+		CurrencyCodeTransaction:            "840",
+	}
+
+	raw, err := request.Pack(nil).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := iso8583.NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var decoded messages.FinancialRequest
+	if err := decoded.Unpack(parsed); err != nil {
+		t.Fatalf("Unpack() error = %v", err)
+	}
+
+	if decoded.PAN != request.PAN || decoded.AmountTransaction != 6000 || decoded.SystemTraceAuditNumber != 1 {
+		t.Errorf("Unexpected round trip result %+v", decoded)
+	}
+	if decoded.DateExpiration == nil || *decoded.DateExpiration != expiry {
+		t.Errorf("Field 14: expected %d, got %v", expiry, decoded.DateExpiration)
+	}
+	if decoded.Track2 != nil || decoded.PersonalIdentificationNumberData != nil {
+		t.Errorf("Expected absent optional fields to stay unset")
+	}
+
+	var response messages.FinancialResponse
+	if err := response.Unpack(parsed); err == nil {
+		t.Errorf("Expected error unpacking a 0200 as a 0210")
+	}
+}
@@ -0,0 +1,83 @@
+// Command iso8583gen generates Go structs with typed fields and
+// reflection free Pack/Unpack methods for ISO 8583 message types.
+//
+// It is meant to be run from go generate:
+//
+//	//go:generate go run iso8583/cmd/iso8583gen -messages messages.json -o messages_gen.go
+//
+// The messages file lists the message types to generate:
+//
+//	{
+//	  "package": "messages",
+//	  "messages": [
+//	    {"name": "FinancialRequest", "mti": "0200", "fields": [2, 3, 4, 11], "optional": [14]}
+//	  ]
+//	}
+//
+// Elements are taken from the built-in spec unless a spec file is
+// given with -spec (see iso8583.LoadSpec for its format).
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"iso8583"
+)
+
+func main() {
+	specFile := flag.String("spec", "", "spec file (defaults to the built-in spec)")
+	messagesFile := flag.String("messages", "", "message types file")
+	output := flag.String("o", "", "output file (defaults to stdout)")
+	importPath := flag.String("import", "iso8583", "import path of the iso8583 package")
+	flag.Parse()
+
+	if err := run(*specFile, *messagesFile, *output, *importPath); err != nil {
+		fmt.Fprintf(os.Stderr, "iso8583gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(specFile, messagesFile, output, importPath string) error {
+	if messagesFile == "" {
+		return fmt.Errorf("-messages is required")
+	}
+
+	spec := iso8583.DefaultSpec
+	if specFile != "" {
+		f, err := os.Open(specFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		spec, err = iso8583.LoadSpec(f)
+		if err != nil {
+			return err
+		}
+	}
+
+	raw, err := os.ReadFile(messagesFile)
+	if err != nil {
+		return err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", messagesFile, err)
+	}
+
+	src, err := generate(spec, cfg, importPath)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+
+	return os.WriteFile(output, src, 0o644)
+}
@@ -0,0 +1,298 @@
+package iso8583
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Subfields of composite elements are addressed by a path made of the
+// field number followed by the position of each sub-element, starting
+// at 1, separated by dots. For example "43.2" is the card acceptor city
+// of field 43 and "127.3.1" the first subfield of the third subfield of
+// field 127. Subfields of elements with a codec are addressed by tag
+// instead, e.g. "55.9F26".
+
+// SetSubfield adds or updates a subfield in the ISO 8583 message. Fields
+// with subfields set are composed from them on Build, replacing any
+// value added with AddField. Build fails for paths that are not a
+// subfield of a defined element, including bare field numbers.
+func (mb *MessageBuilder) SetSubfield(path string, value string) *MessageBuilder {
+	if mb.Subfields == nil {
+		mb.Subfields = make(map[string]string)
+	}
+	mb.Subfields[path] = value
+	return mb
+}
+
+// Subfield returns the value of a subfield set on the builder.
+func (mb *MessageBuilder) Subfield(path string) (string, bool) {
+	value, ok := mb.Subfields[path]
+	return value, ok
+}
+
+// Subfield returns the value of a subfield decomposed by Parse.
+func (m *Parser) Subfield(path string) (string, bool) {
+	value, ok := m.Subfields[path]
+	return value, ok
+}
+
+// SubElement returns the definition of the subfield at path.
+func (s *Spec) SubElement(path string) (Element, bool) {
+	parts := strings.Split(path, ".")
+
+	fieldNum, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Element{}, false
+	}
+
+	elem, ok := s.Element(fieldNum)
+	if !ok {
+		return Element{}, false
+	}
+
+	for i, part := range parts[1:] {
+		if elem.Codec != nil {
+			return elem.Codec.Element(strings.Join(parts[i+1:], "."))
+		}
+
+		index, err := strconv.Atoi(part)
+		if err != nil || index < 1 || index > len(elem.SubElements) {
+			return Element{}, false
+		}
+		elem = elem.SubElements[index-1]
+	}
+
+	return elem, true
+}
+
+// subfieldNumber returns the field number a subfield path belongs to.
+func subfieldNumber(path string) (int, error) {
+	fieldNum, _, _ := strings.Cut(path, ".")
+	return strconv.Atoi(fieldNum)
+}
+
+// hasSubfields reports whether path or any subfield below it is set.
+func hasSubfields(subfields map[string]string, path string) bool {
+	if _, ok := subfields[path]; ok {
+		return true
+	}
+	return hasNestedSubfields(subfields, path)
+}
+
+// hasNestedSubfields reports whether any subfield below path is set.
+func hasNestedSubfields(subfields map[string]string, path string) bool {
+	prefix := path + "."
+	for key := range subfields {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// composeElement builds the value of a composite element from its
+// subfields. Fixed-length elements are composed from all their
+// sub-elements, each formatted by its own content type; variable-length
+// elements leave out trailing subfields that are not set.
+func composeElement(path string, elem Element, subfields map[string]string) (string, error) {
+	if elem.Codec != nil {
+		prefix := path + "."
+		tagged := make(map[string]string)
+		for key, value := range subfields {
+			if strings.HasPrefix(key, prefix) {
+				tagged[key[len(prefix):]] = value
+			}
+		}
+		return elem.Codec.Encode(tagged)
+	}
+
+	if elem.Bitmapped {
+		return composeBitmapped(path, elem, subfields)
+	}
+
+	last := 0
+	for i := range elem.SubElements {
+		if elem.LenType == Fixed || hasSubfields(subfields, path+"."+strconv.Itoa(i+1)) {
+			last = i + 1
+		}
+	}
+
+	var value strings.Builder
+	for i, sub := range elem.SubElements[:last] {
+		encoded, err := composeSubfield(path+"."+strconv.Itoa(i+1), sub, subfields)
+		if err != nil {
+			return "", err
+		}
+		value.WriteString(encoded)
+	}
+
+	return value.String(), nil
+}
+
+// composeBitmapped builds the value of a bitmapped element: its bitmap
+// followed by the subfields that are set.
+func composeBitmapped(path string, elem Element, subfields map[string]string) (string, error) {
+	var present []int
+	for i := 1; i < len(elem.SubElements); i++ {
+		if hasSubfields(subfields, path+"."+strconv.Itoa(i+1)) {
+			present = append(present, i+1)
+		}
+	}
+
+	var value strings.Builder
+	value.WriteString(encodeBitmap(present))
+
+	for _, subNum := range present {
+		encoded, err := composeSubfield(path+"."+strconv.Itoa(subNum), elem.SubElements[subNum-1], subfields)
+		if err != nil {
+			return "", err
+		}
+		value.WriteString(encoded)
+	}
+
+	return value.String(), nil
+}
+
+// composeSubfield formats a subfield, composing it first when it is
+// composite itself.
+func composeSubfield(subPath string, sub Element, subfields map[string]string) (string, error) {
+	subValue := subfields[subPath]
+
+	if sub.composite() && hasNestedSubfields(subfields, subPath) {
+		composed, err := composeElement(subPath, sub, subfields)
+		if err != nil {
+			return "", err
+		}
+		subValue = composed
+	}
+
+	// Parse rejects variable-length subfields over their max length
+	if sub.LenType != Fixed && len(subValue) > sub.MaxLen {
+		return "", fmt.Errorf("subfield %s: length %d exceeds maximum %d", subPath, len(subValue), sub.MaxLen)
+	}
+
+	encoded, err := constructFieldValue(subValue, sub)
+	if err != nil {
+		return "", fmt.Errorf("subfield %s: %v", subPath, err)
+	}
+
+	return encoded, nil
+}
+
+// decomposeElement splits the value of a composite element into its
+// subfields, storing them in subfields by path. Trailing positional
+// subfields missing from the value are left out.
+func decomposeElement(path string, value string, elem Element, subfields map[string]string) error {
+	if elem.Codec != nil {
+		tagged, err := elem.Codec.Decode(value)
+		if err != nil {
+			return err
+		}
+		for tag, tagValue := range tagged {
+			subfields[path+"."+tag] = tagValue
+		}
+		return nil
+	}
+
+	remaining := value
+	subNums := make([]int, len(elem.SubElements))
+	for i := range elem.SubElements {
+		subNums[i] = i + 1
+	}
+
+	if elem.Bitmapped {
+		bitmap, err := decodeBitmap(value)
+		if err != nil {
+			return fmt.Errorf("subfield %s: %v", path, err)
+		}
+		remaining = value[len(bitmap)/4:]
+
+		subNums = subNums[:0]
+		for i, bit := range bitmap[1:] {
+			if bit == '1' {
+				subNums = append(subNums, i+2)
+			}
+		}
+	}
+
+	for _, subNum := range subNums {
+		if remaining == "" && !elem.Bitmapped {
+			break
+		}
+
+		subPath := path + "." + strconv.Itoa(subNum)
+		if subNum > len(elem.SubElements) {
+			return fmt.Errorf("no definition for subfield %s", subPath)
+		}
+		sub := elem.SubElements[subNum-1]
+
+		subValue, rest, err := decodeElement(remaining, sub)
+		if err != nil {
+			return fmt.Errorf("subfield %s: %v", subPath, err)
+		}
+		subfields[subPath] = subValue
+
+		if sub.composite() {
+			if err := decomposeElement(subPath, subValue, sub, subfields); err != nil {
+				return err
+			}
+		}
+
+		remaining = rest
+	}
+
+	if remaining != "" {
+		return fmt.Errorf("%d characters left after the subfields of %s", len(remaining), path)
+	}
+
+	return nil
+}
+
+// decodeElement reads an element from the start of input, returning its
+// value and the rest of the input.
+func decodeElement(input string, elem Element) (value string, remaining string, err error) {
+	var raw string
+
+	switch elem.LenType {
+	case Fixed:
+		width := elem.Encoding.width(elem.MaxLen)
+		if len(input) < width {
+			return "", "", fmt.Errorf("not enough data for fixed-length element")
+		}
+		raw, remaining = input[:width], input[width:]
+
+	case LLVAR, LLLVAR:
+		digits := 2
+		if elem.LenType == LLLVAR {
+			digits = 3
+		}
+		if len(input) < digits {
+			return "", "", fmt.Errorf("input too short for %s length indicator", elem.LenType)
+		}
+
+		length, err := strconv.Atoi(input[:digits])
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse %s length indicator: %v", elem.LenType, err)
+		}
+		if length > elem.MaxLen {
+			return "", "", fmt.Errorf("length %d exceeds maximum %d", length, elem.MaxLen)
+		}
+
+		width := elem.Encoding.width(length)
+		if len(input)-digits < width {
+			return "", "", fmt.Errorf("not enough data for %s element", elem.LenType)
+		}
+		raw, remaining = input[digits:digits+width], input[digits+width:]
+
+	default:
+		return "", "", fmt.Errorf("unsupported length type %d", elem.LenType)
+	}
+
+	value, err = elem.Encoding.decode(raw)
+	if err != nil {
+		return "", "", err
+	}
+
+	return value, remaining, nil
+}
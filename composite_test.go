@@ -0,0 +1,234 @@
+package iso8583
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompositeField(t *testing.T) {
+	msg := NewISO()
+	msg.SetMTI("0200")
+	msg.AddField(11, "000001")
+	msg.SetSubfield("43.1", "ACME STORE")
+	msg.SetSubfield("43.2", "SAO PAULO")
+	msg.SetSubfield("43.3", "BR")
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	expected := "ACME STORE               SAO PAULO    BR"
+	if parsed.Fields[43] != expected {
+		t.Errorf("Field 43: expected %q, got %q", expected, parsed.Fields[43])
+	}
+
+	subfields := map[string]string{
+		"43.1": "ACME STORE               ",
+		"43.2": "SAO PAULO    ",
+		"43.3": "BR",
+	}
+	for path, expectedValue := range subfields {
+		if value, ok := parsed.Subfield(path); !ok || value != expectedValue {
+			t.Errorf("Subfield %s: expected %q, got %q", path, expectedValue, value)
+		}
+	}
+}
+
+func TestPartialFixedCompositeField(t *testing.T) {
+	msg := NewISO()
+	msg.SetMTI("0400")
+	msg.SetSubfield("90.1", "0200")
+	msg.SetSubfield("90.2", "000123")
+	msg.SetSubfield("95.1", "000000001000")
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	expected90 := "0200" + "000123" + strings.Repeat("0", 32)
+	if parsed.Fields[90] != expected90 {
+		t.Errorf("Field 90: expected %s, got %s", expected90, parsed.Fields[90])
+	}
+
+	expected95 := "000000001000" + strings.Repeat("0", 12) + "C00000000" + "C00000000"
+	if parsed.Fields[95] != expected95 {
+		t.Errorf("Field 95: expected %s, got %s", expected95, parsed.Fields[95])
+	}
+
+	subfields := map[string]string{
+		"90.1": "0200",
+		"90.2": "000123",
+		"90.5": "00000000000",
+		"95.1": "000000001000",
+		"95.3": "C00000000",
+		"95.4": "C00000000",
+	}
+	for path, expectedValue := range subfields {
+		if value, ok := parsed.Subfield(path); !ok || value != expectedValue {
+			t.Errorf("Subfield %s: expected %q, got %q", path, expectedValue, value)
+		}
+	}
+
+	msg.SetSubfield("95.3", "X12")
+	if _, err := msg.Build(); err == nil {
+		t.Errorf("Expected error for invalid signed amount in subfield 95.3")
+	}
+}
+
+func TestNestedCompositeField(t *testing.T) {
+	spec := &Spec{Elements: map[int]Element{
+		3: DefaultSpec.Elements[3],
+		62: {ContentType: "ans", Label: "Private data", LenType: LLLVAR, MaxLen: 999, SubElements: []Element{
+			{ContentType: "n", Label: "Version", LenType: Fixed, MaxLen: 2},
+			{ContentType: "ans", Label: "Device", LenType: LLVAR, MaxLen: 30, SubElements: []Element{
+				{ContentType: "an", Label: "Type", LenType: Fixed, MaxLen: 3},
+				{ContentType: "ans", Label: "Serial", LenType: LLVAR, MaxLen: 20, Encoding: Hex},
+			}},
+			{ContentType: "ans", Label: "Note", LenType: LLVAR, MaxLen: 20},
+		}},
+	}}
+
+	msg := NewISOWithSpec(spec)
+	msg.SetMTI("0100")
+	msg.AddField(3, "000000")
+	msg.SetSubfield("62.1", "1")
+	msg.SetSubfield("62.2.1", "POS")
+	msg.SetSubfield("62.2.2", "AB12")
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	expected := "0100200000000000000400000001701" + "13" + "POS" + "04" + "41423132"
+	if raw != expected {
+		t.Errorf("Expected ISO message = %s, got %s", expected, raw)
+	}
+
+	parsed, err := NewParserWithSpec(spec).Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	for path, expectedValue := range map[string]string{"62.1": "01", "62.2.1": "POS", "62.2.2": "AB12"} {
+		if value, ok := parsed.Subfield(path); !ok || value != expectedValue {
+			t.Errorf("Subfield %s: expected %q, got %q", path, expectedValue, value)
+		}
+	}
+
+	if _, ok := parsed.Subfield("62.3"); ok {
+		t.Errorf("Subfield 62.3: expected trailing subfield to be absent")
+	}
+
+	if _, err := NewISO().SetMTI("0100").SetSubfield("43", "ACME STORE").Build(); err == nil {
+		t.Errorf("Expected error for subfield path without subfield")
+	}
+
+	if _, err := NewISOWithSpec(spec).SetMTI("0100").SetSubfield("62.4", "x").Build(); err == nil {
+		t.Errorf("Expected error for undefined subfield")
+	}
+
+	if _, err := NewISOWithSpec(spec).SetMTI("0100").SetSubfield("62.3", strings.Repeat("X", 21)).Build(); err == nil {
+		t.Errorf("Expected error for subfield over its max length")
+	}
+}
+
+func TestSpecSubElement(t *testing.T) {
+	elem, ok := DefaultSpec.SubElement("90.2")
+	if !ok || elem.Label != "Original system trace audit number" || elem.MaxLen != 6 {
+		t.Errorf("Subelement 90.2: unexpected definition %+v", elem)
+	}
+
+	for _, path := range []string{"90.6", "90.x", "2.1", "x"} {
+		if _, ok := DefaultSpec.SubElement(path); ok {
+			t.Errorf("Subelement %s: expected not to be defined", path)
+		}
+	}
+}
+
+func TestDiffSubfields(t *testing.T) {
+	a := NewParser()
+	a.MTI = "0400"
+	a.Fields[90] = "020000012302091234560000012345600000000000"
+	a.Subfields["90.1"] = "0200"
+	a.Subfields["90.2"] = "000123"
+
+	b := NewParser()
+	b.MTI = "0400"
+	b.Fields[90] = "020000012402091234560000012345600000000000"
+	b.Subfields["90.1"] = "0200"
+	b.Subfields["90.2"] = "000124"
+
+	diff := Diff(a, b)
+	if len(diff) != 2 {
+		t.Fatalf("Expected 2 differences, got:\n%s", diff)
+	}
+
+	expected := FieldDiff{Field: 90, Path: "90.2", Label: "Original system trace audit number", Kind: Changed, Old: "000123", New: "000124"}
+	if diff[1] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, diff[1])
+	}
+}
+
+func TestBitmappedCompositeField(t *testing.T) {
+	spec := DefaultSpec.Clone()
+	spec.Elements[127] = Element{ContentType: "ans", Label: "Private data", LenType: LLLVAR, MaxLen: 999, Bitmapped: true, SubElements: []Element{
+		{ContentType: "b", Label: "Bitmap", LenType: Fixed, MaxLen: 8},
+		{ContentType: "ans", Label: "Switch key", LenType: LLVAR, MaxLen: 32},
+		{ContentType: "ans", Label: "Routing information", LenType: Fixed, MaxLen: 8},
+		{ContentType: "n", Label: "Batch number", LenType: Fixed, MaxLen: 4},
+		{ContentType: "ans", Label: "Extended data", LenType: LLLVAR, MaxLen: 200, Bitmapped: true, SubElements: []Element{
+			{ContentType: "b", Label: "Bitmap", LenType: Fixed, MaxLen: 8},
+			{ContentType: "an", Label: "Channel", LenType: Fixed, MaxLen: 3},
+		}},
+	}}
+
+	msg := NewISOWithSpec(spec)
+	msg.SetMTI("0200")
+	msg.AddField(3, "000000")
+	msg.SetSubfield("127.2", "KEY123")
+	msg.SetSubfield("127.4", "17")
+	msg.SetSubfield("127.5.2", "POS")
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	field127 := "5800000000000000" + "06KEY123" + "0017" + "019" + "4000000000000000" + "POS"
+	if !strings.HasSuffix(raw, fmt.Sprintf("%03d", len(field127))+field127) {
+		t.Errorf("Expected field 127 = %s, got %s", field127, raw)
+	}
+
+	parsed, err := NewParserWithSpec(spec).Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	for path, expectedValue := range map[string]string{"127.2": "KEY123", "127.4": "0017", "127.5.2": "POS"} {
+		if value, ok := parsed.Subfield(path); !ok || value != expectedValue {
+			t.Errorf("Subfield %s: expected %q, got %q", path, expectedValue, value)
+		}
+	}
+
+	if _, ok := parsed.Subfield("127.3"); ok {
+		t.Errorf("Subfield 127.3: expected to be absent")
+	}
+
+	if err := decomposeElement("127", "0200000000000000", spec.Elements[127], map[string]string{}); err == nil {
+		t.Errorf("Expected error for subfield without definition")
+	}
+}
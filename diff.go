@@ -0,0 +1,205 @@
+package iso8583
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffKind describes how a field differs between two messages.
+type DiffKind int
+
+// List of diff kinds.
+const (
+	Added DiffKind = iota
+	Removed
+	Changed
+)
+
+// String returns the string representation of the diff kind.
+func (k DiffKind) String() string {
+	return [...]string{"added", "removed", "changed"}[k]
+}
+
+// FieldDiff describes a difference in one field or subfield of two
+// messages. Field 0 is the MTI, and Path is set for subfields.
+type FieldDiff struct {
+	Field int
+	Path  string
+	Label string
+	Kind  DiffKind
+	Old   string
+	New   string
+}
+
+// String renders the difference on a single line.
+func (d FieldDiff) String() string {
+	name := "field " + strconv.Itoa(d.Field)
+	if d.Path != "" {
+		name = "subfield " + d.Path
+	}
+
+	switch d.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s (%s): %q", name, d.Label, d.New)
+	case Removed:
+		return fmt.Sprintf("- %s (%s): %q", name, d.Label, d.Old)
+	default:
+		return fmt.Sprintf("~ %s (%s): %q => %q", name, d.Label, d.Old, d.New)
+	}
+}
+
+// MessageDiff lists the differences between two messages ordered by field.
+type MessageDiff []FieldDiff
+
+// Equal reports whether the messages have no differences.
+func (d MessageDiff) Equal() bool {
+	return len(d) == 0
+}
+
+// String renders the differences one per line, suitable for test
+// failure output.
+func (d MessageDiff) String() string {
+	var out strings.Builder
+	for _, fd := range d {
+		out.WriteString(fd.String())
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// Diff compares two parsed messages field by field, reporting fields
+// present only in b as added, only in a as removed and with different
+// values as changed. Differences in the subfields of a field follow the
+// field itself. Labels are taken from the spec of a.
+func Diff(a, b *Parser) MessageDiff {
+	spec := a.Spec()
+
+	var diff MessageDiff
+
+	if a.MTI != b.MTI {
+		diff = append(diff, FieldDiff{Field: 0, Label: label(spec, 0), Kind: Changed, Old: a.MTI, New: b.MTI})
+	}
+
+	fieldNums := make(map[int]bool)
+	for fieldNum := range a.Fields {
+		fieldNums[fieldNum] = true
+	}
+	for fieldNum := range b.Fields {
+		fieldNums[fieldNum] = true
+	}
+
+	subfieldPaths := make(map[int][]string)
+	for _, subfields := range []map[string]string{a.Subfields, b.Subfields} {
+		for path := range subfields {
+			fieldNum, err := subfieldNumber(path)
+			if err != nil {
+				continue
+			}
+			fieldNums[fieldNum] = true
+			subfieldPaths[fieldNum] = append(subfieldPaths[fieldNum], path)
+		}
+	}
+
+	sorted := make([]int, 0, len(fieldNums))
+	for fieldNum := range fieldNums {
+		sorted = append(sorted, fieldNum)
+	}
+	sort.Ints(sorted)
+
+	for _, fieldNum := range sorted {
+		oldValue, inA := a.Fields[fieldNum]
+		newValue, inB := b.Fields[fieldNum]
+
+		fd := FieldDiff{Field: fieldNum, Label: label(spec, fieldNum), Old: oldValue, New: newValue}
+		if diffKind(&fd, inA, inB) {
+			diff = append(diff, fd)
+		}
+
+		paths := subfieldPaths[fieldNum]
+		sort.Slice(paths, func(i, j int) bool { return lessPath(paths[i], paths[j]) })
+
+		for i, path := range paths {
+			if i > 0 && paths[i-1] == path {
+				continue
+			}
+
+			oldValue, inA := a.Subfields[path]
+			newValue, inB := b.Subfields[path]
+
+			fd := FieldDiff{Field: fieldNum, Path: path, Old: oldValue, New: newValue}
+			if elem, ok := spec.SubElement(path); ok {
+				fd.Label = elem.Label
+			} else {
+				fd.Label = "unknown"
+			}
+
+			if diffKind(&fd, inA, inB) {
+				diff = append(diff, fd)
+			}
+		}
+	}
+
+	return diff
+}
+
+// diffKind sets the kind of a difference from the presence and values
+// of a field in both messages, reporting false if they are equal.
+func diffKind(fd *FieldDiff, inA, inB bool) bool {
+	switch {
+	case !inA:
+		fd.Kind = Added
+	case !inB:
+		fd.Kind = Removed
+	case fd.Old != fd.New:
+		fd.Kind = Changed
+	default:
+		return false
+	}
+	return true
+}
+
+// lessPath orders subfield paths by their numeric components.
+func lessPath(a, b string) bool {
+	partsA := strings.Split(a, ".")
+	partsB := strings.Split(b, ".")
+
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] == partsB[i] {
+			continue
+		}
+
+		numA, errA := strconv.Atoi(partsA[i])
+		numB, errB := strconv.Atoi(partsB[i])
+		if errA == nil && errB == nil {
+			return numA < numB
+		}
+		return partsA[i] < partsB[i]
+	}
+
+	return len(partsA) < len(partsB)
+}
+
+// DiffRaw parses two raw messages with the given spec and compares them.
+func DiffRaw(spec *Spec, a, b string) (MessageDiff, error) {
+	parsedA, err := NewParserWithSpec(spec).Parse(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse first message: %v", err)
+	}
+
+	parsedB, err := NewParserWithSpec(spec).Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse second message: %v", err)
+	}
+
+	return Diff(parsedA, parsedB), nil
+}
+
+// label returns the label of a field in the spec.
+func label(spec *Spec, fieldNum int) string {
+	if elem, ok := spec.Element(fieldNum); ok {
+		return elem.Label
+	}
+	return "unknown"
+}
@@ -0,0 +1,74 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := NewParser()
+	a.MTI = "0200"
+	a.Fields[3] = "000000"
+	a.Fields[4] = "000000006000"
+	a.Fields[11] = "000001"
+
+	b := NewParser()
+	b.MTI = "0210"
+	b.Fields[3] = "000000"
+	b.Fields[4] = "000000007000"
+	b.Fields[39] = "00"
+
+	diff := Diff(a, b)
+
+	expected := MessageDiff{
+		{Field: 0, Label: "Message Type Indicator", Kind: Changed, Old: "0200", New: "0210"},
+		{Field: 4, Label: "Amount, transaction", Kind: Changed, Old: "000000006000", New: "000000007000"},
+		{Field: 11, Label: "System trace audit number", Kind: Removed, Old: "000001"},
+		{Field: 39, Label: "Response code", Kind: Added, New: "00"},
+	}
+
+	if len(diff) != len(expected) {
+		t.Fatalf("Expected %d differences, got %d:\n%s", len(expected), len(diff), diff)
+	}
+
+	for i := range expected {
+		if diff[i] != expected[i] {
+			t.Errorf("Difference %d: expected %+v, got %+v", i, expected[i], diff[i])
+		}
+	}
+
+	text := "~ field 0 (Message Type Indicator): \"0200\" => \"0210\"\n" +
+		"~ field 4 (Amount, transaction): \"000000006000\" => \"000000007000\"\n" +
+		"- field 11 (System trace audit number): \"000001\"\n" +
+		"+ field 39 (Response code): \"00\"\n"
+	if diff.String() != text {
+		t.Errorf("Expected rendering:\n%s\ngot:\n%s", text, diff.String())
+	}
+
+	if !Diff(a, a).Equal() {
+		t.Errorf("Expected a message to equal itself")
+	}
+}
+
+func TestDiffRaw(t *testing.T) {
+	a, err := NewISO().SetMTI("0800").AddField(11, "000001").AddField(70, "301").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewISO().SetMTI("0800").AddField(11, "000002").AddField(70, "301").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := DiffRaw(DefaultSpec, a, b)
+	if err != nil {
+		t.Fatalf("DiffRaw() error = %v", err)
+	}
+
+	if len(diff) != 1 || diff[0].Field != 11 || diff[0].Kind != Changed {
+		t.Errorf("Expected field 11 to be changed, got:\n%s", diff)
+	}
+
+	if _, err := DiffRaw(DefaultSpec, "01", b); err == nil {
+		t.Errorf("Expected error for invalid message")
+	}
+}
@@ -1,5 +1,11 @@
 package iso8583
 
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
 // LenType represents the length type of an ISO 8583 element.
 type LenType int
 
@@ -10,6 +16,18 @@ const (
 	LLLVAR
 )
 
+// Encoding represents how an element value is represented in the message.
+type Encoding int
+
+// List of encodings.
+const (
+	// ASCII elements are written as is.
+	ASCII Encoding = iota
+	// Hex elements are written as uppercase hexadecimal, two characters
+	// per byte of value. Lengths still count value bytes.
+	Hex
+)
+
 // Element represents an ISO 8583 element.
 type Element struct {
 	ContentType string
@@ -17,6 +35,36 @@ type Element struct {
 	LenType     LenType
 	MaxLen      int
 	MinLen      int
+	Encoding    Encoding
+
+	// SubElements are the positional subfields of a composite element,
+	// numbered from 1 in order. Sub-elements may be composite themselves.
+	SubElements []Element
+
+	// Bitmapped elements start with their own bitmap flagging which
+	// sub-elements are present, like a nested message. As in a message,
+	// bit 1 flags a secondary bitmap, so the first sub-element describes
+	// the bitmap itself and is never set.
+	Bitmapped bool
+
+	// Codec decomposes the element into tagged subfields instead.
+	Codec SubfieldCodec `json:"-"`
+}
+
+// SubfieldCodec decomposes an element value into subfields keyed by tag
+// and composes it back, for elements whose subfields are not positional.
+type SubfieldCodec interface {
+	// Decode splits a value into subfields keyed by tag.
+	Decode(value string) (map[string]string, error)
+	// Encode composes a value from subfields keyed by tag.
+	Encode(subfields map[string]string) (string, error)
+	// Element returns the definition of the subfield with the given tag.
+	Element(tag string) (Element, bool)
+}
+
+// composite reports whether the element is made of subfields.
+func (e Element) composite() bool {
+	return len(e.SubElements) > 0 || e.Codec != nil
 }
 
 // String returns the string representation of the length type.
@@ -24,6 +72,77 @@ func (l LenType) String() string {
 	return [...]string{"Fixed", "LLVAR", "LLLVAR"}[l]
 }
 
+// MarshalText encodes the length type as its name.
+func (l LenType) MarshalText() ([]byte, error) {
+	if l < Fixed || l > LLLVAR {
+		return nil, fmt.Errorf("invalid length type %d", l)
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a length type from its name.
+func (l *LenType) UnmarshalText(text []byte) error {
+	for _, lt := range []LenType{Fixed, LLVAR, LLLVAR} {
+		if string(text) == lt.String() {
+			*l = lt
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid length type %q", text)
+}
+
+// String returns the string representation of the encoding.
+func (e Encoding) String() string {
+	return [...]string{"ASCII", "Hex"}[e]
+}
+
+// MarshalText encodes the encoding as its name.
+func (e Encoding) MarshalText() ([]byte, error) {
+	if e < ASCII || e > Hex {
+		return nil, fmt.Errorf("invalid encoding %d", e)
+	}
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText decodes an encoding from its name.
+func (e *Encoding) UnmarshalText(text []byte) error {
+	for _, enc := range []Encoding{ASCII, Hex} {
+		if string(text) == enc.String() {
+			*e = enc
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid encoding %q", text)
+}
+
+// width returns the number of message characters used by n value bytes.
+func (e Encoding) width(n int) int {
+	if e == Hex {
+		return n * 2
+	}
+	return n
+}
+
+// encode converts a value to its message representation.
+func (e Encoding) encode(value string) string {
+	if e == Hex {
+		return strings.ToUpper(hex.EncodeToString([]byte(value)))
+	}
+	return value
+}
+
+// decode converts a message representation back to its value.
+func (e Encoding) decode(raw string) (string, error) {
+	if e == Hex {
+		value, err := hex.DecodeString(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid hex data: %v", err)
+		}
+		return string(value), nil
+	}
+	return raw, nil
+}
+
 // NewElement initializes a new ISO 8583 element.
 // have a chance to customize the elements for your own use case
 // need to be careful when changing the elements and change
@@ -57,10 +176,10 @@ var dataElem = map[int]Element{
 	25:  {ContentType: "n", Label: "Point of service condition code", LenType: Fixed, MaxLen: 2},
 	26:  {ContentType: "n", Label: "Point of service capture code", LenType: Fixed, MaxLen: 2},
 	27:  {ContentType: "n", Label: "Authorizing identification response length", LenType: Fixed, MaxLen: 1},
-	28:  {ContentType: "an", Label: "Amount, transaction fee", LenType: Fixed, MaxLen: 9},
-	29:  {ContentType: "an", Label: "Amount, settlement fee", LenType: Fixed, MaxLen: 9},
-	30:  {ContentType: "an", Label: "Amount, transaction processing fee", LenType: Fixed, MaxLen: 9},
-	31:  {ContentType: "an", Label: "Amount, settlement processing fee", LenType: Fixed, MaxLen: 9},
+	28:  {ContentType: "x+n", Label: "Amount, transaction fee", LenType: Fixed, MaxLen: 9},
+	29:  {ContentType: "x+n", Label: "Amount, settlement fee", LenType: Fixed, MaxLen: 9},
+	30:  {ContentType: "x+n", Label: "Amount, transaction processing fee", LenType: Fixed, MaxLen: 9},
+	31:  {ContentType: "x+n", Label: "Amount, settlement processing fee", LenType: Fixed, MaxLen: 9},
 	32:  {ContentType: "n", Label: "Acquiring institution identification code", LenType: LLVAR, MaxLen: 11},
 	33:  {ContentType: "n", Label: "Forwarding institution identification code", LenType: LLVAR, MaxLen: 11},
 	34:  {ContentType: "ns", Label: "Primary account number, extended", LenType: LLVAR, MaxLen: 28},
@@ -72,7 +191,7 @@ var dataElem = map[int]Element{
 	40:  {ContentType: "an", Label: "Service restriction code", LenType: Fixed, MaxLen: 3},
 	41:  {ContentType: "ans", Label: "Card acceptor terminal identification", LenType: Fixed, MaxLen: 8},
 	42:  {ContentType: "ans", Label: "Card acceptor identification code", LenType: Fixed, MaxLen: 15},
-	43:  {ContentType: "ans", Label: "Card acceptor name/location", LenType: Fixed, MaxLen: 40},
+	43:  {ContentType: "ans", Label: "Card acceptor name/location", LenType: Fixed, MaxLen: 40, SubElements: field43Elements},
 	44:  {ContentType: "an", Label: "Additional response data", LenType: LLVAR, MaxLen: 25},
 	45:  {ContentType: "an", Label: "Track 1 data", LenType: LLVAR, MaxLen: 76},
 	46:  {ContentType: "an", Label: "Additional data - ISO", LenType: LLLVAR, MaxLen: 999},
@@ -83,8 +202,8 @@ var dataElem = map[int]Element{
 	51:  {ContentType: "an", Label: "Currency code, cardholder billing", LenType: Fixed, MaxLen: 3},
 	52:  {ContentType: "b", Label: "Personal identification number data", LenType: Fixed, MaxLen: 8},
 	53:  {ContentType: "n", Label: "Security related control information", LenType: Fixed, MaxLen: 16},
-	54:  {ContentType: "an", Label: "Additional amounts", LenType: LLLVAR, MaxLen: 120},
-	55:  {ContentType: "ans", Label: "Reserved ISO", LenType: LLLVAR, MaxLen: 999},
+	54:  {ContentType: "an", Label: "Additional amounts", LenType: LLLVAR, MaxLen: 120, Codec: AdditionalAmountsCodec{}},
+	55:  {ContentType: "ans", Label: "ICC data (EMV)", LenType: LLLVAR, MaxLen: 999},
 	56:  {ContentType: "ans", Label: "Reserved ISO", LenType: LLLVAR, MaxLen: 999},
 	57:  {ContentType: "ans", Label: "Reserved national", LenType: LLLVAR, MaxLen: 999},
 	58:  {ContentType: "ans", Label: "Reserved national", LenType: LLLVAR, MaxLen: 999},
@@ -119,14 +238,14 @@ var dataElem = map[int]Element{
 	87:  {ContentType: "n", Label: "Credits, reversal amount", LenType: Fixed, MaxLen: 16},
 	88:  {ContentType: "n", Label: "Debits, amount", LenType: Fixed, MaxLen: 16},
 	89:  {ContentType: "n", Label: "Debits, reversal amount", LenType: Fixed, MaxLen: 16},
-	90:  {ContentType: "n", Label: "Original data elements", LenType: Fixed, MaxLen: 42},
+	90:  {ContentType: "n", Label: "Original data elements", LenType: Fixed, MaxLen: 42, SubElements: field90Elements},
 	91:  {ContentType: "an", Label: "File update code", LenType: Fixed, MaxLen: 1},
 	92:  {ContentType: "an", Label: "File security code", LenType: Fixed, MaxLen: 2},
 	93:  {ContentType: "an", Label: "Response indicator", LenType: Fixed, MaxLen: 5},
 	94:  {ContentType: "an", Label: "Service indicator", LenType: Fixed, MaxLen: 7},
-	95:  {ContentType: "an", Label: "Replacement amounts", LenType: Fixed, MaxLen: 42},
+	95:  {ContentType: "an", Label: "Replacement amounts", LenType: Fixed, MaxLen: 42, SubElements: field95Elements},
 	96:  {ContentType: "b", Label: "Message security code", LenType: Fixed, MaxLen: 8},
-	97:  {ContentType: "an", Label: "Amount, net settlement", LenType: Fixed, MaxLen: 17},
+	97:  {ContentType: "x+n", Label: "Amount, net settlement", LenType: Fixed, MaxLen: 17},
 	98:  {ContentType: "ans", Label: "Payee", LenType: Fixed, MaxLen: 25},
 	99:  {ContentType: "n", Label: "Settlement institution identification code", LenType: LLVAR, MaxLen: 11},
 	100: {ContentType: "n", Label: "Receiving institution identification code", LenType: LLVAR, MaxLen: 11},
@@ -159,3 +278,27 @@ var dataElem = map[int]Element{
 	127: {ContentType: "ans", Label: "Reserved for private use", LenType: LLLVAR, MaxLen: 999},
 	128: {ContentType: "b", Label: "Message authentication code", LenType: Fixed, MaxLen: 8},
 }
+
+// field43Elements are the subfields of field 43.
+var field43Elements = []Element{
+	{ContentType: "ans", Label: "Card acceptor name", LenType: Fixed, MaxLen: 25},
+	{ContentType: "ans", Label: "Card acceptor city", LenType: Fixed, MaxLen: 13},
+	{ContentType: "an", Label: "Card acceptor country code", LenType: Fixed, MaxLen: 2},
+}
+
+// field90Elements are the subfields of field 90.
+var field90Elements = []Element{
+	{ContentType: "n", Label: "Original message type indicator", LenType: Fixed, MaxLen: 4},
+	{ContentType: "n", Label: "Original system trace audit number", LenType: Fixed, MaxLen: 6},
+	{ContentType: "n", Label: "Original transmission date & time", LenType: Fixed, MaxLen: 10},
+	{ContentType: "n", Label: "Original acquiring institution identification code", LenType: Fixed, MaxLen: 11},
+	{ContentType: "n", Label: "Original forwarding institution identification code", LenType: Fixed, MaxLen: 11},
+}
+
+// field95Elements are the subfields of field 95.
+var field95Elements = []Element{
+	{ContentType: "n", Label: "Actual amount, transaction", LenType: Fixed, MaxLen: 12},
+	{ContentType: "n", Label: "Actual amount, settlement", LenType: Fixed, MaxLen: 12},
+	{ContentType: "x+n", Label: "Actual amount, transaction fee", LenType: Fixed, MaxLen: 9},
+	{ContentType: "x+n", Label: "Actual amount, settlement fee", LenType: Fixed, MaxLen: 9},
+}
@@ -0,0 +1,45 @@
+package iso8583
+
+// EMVTags is a dictionary of common EMV tags carried in field 55. The
+// content type is the EMV format (b, n, cn, an, ans) and the lengths are
+// in bytes.
+var EMVTags = map[string]Element{
+	"4F":   {ContentType: "b", Label: "Application Identifier (AID) - card", LenType: LLVAR, MaxLen: 16, MinLen: 5},
+	"50":   {ContentType: "ans", Label: "Application Label", LenType: LLVAR, MaxLen: 16, MinLen: 1},
+	"57":   {ContentType: "b", Label: "Track 2 Equivalent Data", LenType: LLVAR, MaxLen: 19},
+	"5A":   {ContentType: "cn", Label: "Application Primary Account Number (PAN)", LenType: LLVAR, MaxLen: 10},
+	"5F20": {ContentType: "ans", Label: "Cardholder Name", LenType: LLVAR, MaxLen: 26, MinLen: 2},
+	"5F24": {ContentType: "n", Label: "Application Expiration Date", LenType: Fixed, MaxLen: 3},
+	"5F25": {ContentType: "n", Label: "Application Effective Date", LenType: Fixed, MaxLen: 3},
+	"5F28": {ContentType: "n", Label: "Issuer Country Code", LenType: Fixed, MaxLen: 2},
+	"5F2A": {ContentType: "n", Label: "Transaction Currency Code", LenType: Fixed, MaxLen: 2},
+	"5F34": {ContentType: "n", Label: "Application PAN Sequence Number", LenType: Fixed, MaxLen: 1},
+	"71":   {ContentType: "b", Label: "Issuer Script Template 1", LenType: LLVAR, MaxLen: 128},
+	"72":   {ContentType: "b", Label: "Issuer Script Template 2", LenType: LLVAR, MaxLen: 128},
+	"82":   {ContentType: "b", Label: "Application Interchange Profile", LenType: Fixed, MaxLen: 2},
+	"84":   {ContentType: "b", Label: "Dedicated File (DF) Name", LenType: LLVAR, MaxLen: 16, MinLen: 5},
+	"8A":   {ContentType: "an", Label: "Authorisation Response Code", LenType: Fixed, MaxLen: 2},
+	"91":   {ContentType: "b", Label: "Issuer Authentication Data", LenType: LLVAR, MaxLen: 16, MinLen: 8},
+	"95":   {ContentType: "b", Label: "Terminal Verification Results", LenType: Fixed, MaxLen: 5},
+	"9A":   {ContentType: "n", Label: "Transaction Date", LenType: Fixed, MaxLen: 3},
+	"9B":   {ContentType: "b", Label: "Transaction Status Information", LenType: Fixed, MaxLen: 2},
+	"9C":   {ContentType: "n", Label: "Transaction Type", LenType: Fixed, MaxLen: 1},
+	"9F02": {ContentType: "n", Label: "Amount, Authorised (Numeric)", LenType: Fixed, MaxLen: 6},
+	"9F03": {ContentType: "n", Label: "Amount, Other (Numeric)", LenType: Fixed, MaxLen: 6},
+	"9F06": {ContentType: "b", Label: "Application Identifier (AID) - terminal", LenType: LLVAR, MaxLen: 16, MinLen: 5},
+	"9F07": {ContentType: "b", Label: "Application Usage Control", LenType: Fixed, MaxLen: 2},
+	"9F09": {ContentType: "b", Label: "Application Version Number", LenType: Fixed, MaxLen: 2},
+	"9F10": {ContentType: "b", Label: "Issuer Application Data", LenType: LLVAR, MaxLen: 32},
+	"9F1A": {ContentType: "n", Label: "Terminal Country Code", LenType: Fixed, MaxLen: 2},
+	"9F1E": {ContentType: "an", Label: "Interface Device (IFD) Serial Number", LenType: Fixed, MaxLen: 8},
+	"9F26": {ContentType: "b", Label: "Application Cryptogram", LenType: Fixed, MaxLen: 8},
+	"9F27": {ContentType: "b", Label: "Cryptogram Information Data", LenType: Fixed, MaxLen: 1},
+	"9F33": {ContentType: "b", Label: "Terminal Capabilities", LenType: Fixed, MaxLen: 3},
+	"9F34": {ContentType: "b", Label: "Cardholder Verification Method (CVM) Results", LenType: Fixed, MaxLen: 3},
+	"9F35": {ContentType: "n", Label: "Terminal Type", LenType: Fixed, MaxLen: 1},
+	"9F36": {ContentType: "b", Label: "Application Transaction Counter (ATC)", LenType: Fixed, MaxLen: 2},
+	"9F37": {ContentType: "b", Label: "Unpredictable Number", LenType: Fixed, MaxLen: 4},
+	"9F41": {ContentType: "n", Label: "Transaction Sequence Counter", LenType: LLVAR, MaxLen: 4, MinLen: 2},
+	"9F53": {ContentType: "an", Label: "Transaction Category Code", LenType: Fixed, MaxLen: 1},
+	"9F6E": {ContentType: "b", Label: "Form Factor Indicator", LenType: LLVAR, MaxLen: 32},
+}
@@ -38,7 +38,7 @@ func main() {
 	msg.AddField(48, "XPTO")                 // Additional Data - Private
 	msg.AddField(49, "840")                  // Currency Code, Transaction
 	msg.AddField(51, "840")                  // Currency Code, Cardholder Billing
-	msg.AddField(54, "CSHB840D0000002000")   // Cashback amount              // Currency Code, Cardholder Billing
+	msg.AddField(54, "0040840C000000002000") // Additional Amounts, cash back
 	msg.AddField(53, "2600000000000000")     // Security Related Control Information
 	msg.AddField(57, "2500")                 // Amount, Cash
 	msg.AddField(58, "1234")                 // Authorizing Agent Institution ID
@@ -0,0 +1,4 @@
+// Package messages contains typed message structs generated by iso8583gen.
+package messages
+
+//go:generate go run iso8583/cmd/iso8583gen -messages messages.json -o messages_gen.go
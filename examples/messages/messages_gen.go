@@ -0,0 +1,238 @@
+// Code generated by iso8583gen; DO NOT EDIT.
+
+package messages
+
+import (
+	"fmt"
+	"strconv"
+
+	"iso8583"
+)
+
+// FinancialRequest is the 0200 message.
+type FinancialRequest struct {
+	// PAN is field 2, Primary account number (PAN).
+	PAN string
+	// ProcessingCode is field 3, Processing code.
+	ProcessingCode int64
+	// AmountTransaction is field 4, Amount, transaction.
+	AmountTransaction int64
+	// TransmissionDateTime is field 7, Transmission date & time.
+	TransmissionDateTime int64
+	// SystemTraceAuditNumber is field 11, System trace audit number.
+	SystemTraceAuditNumber int64
+	// DateExpiration is field 14, Date, expiration.
+	DateExpiration *int64
+	// Track2 is field 35, Track 2 data.
+	Track2 *string
+	// CardAcceptorTerminalIdentification is field 41, Card acceptor terminal identification.
+	CardAcceptorTerminalIdentification string
+	// CurrencyCodeTransaction is field 49, Currency code, transaction.
+	CurrencyCodeTransaction string
+	// PersonalIdentificationNumberData is field 52, Personal identification number data.
+	PersonalIdentificationNumberData []byte
+}
+
+// Pack creates a 0200 message builder from m using spec, or the
+// default spec when spec is nil.
+func (m *FinancialRequest) Pack(spec *iso8583.Spec) *iso8583.MessageBuilder {
+	mb := iso8583.NewISOWithSpec(spec)
+	mb.SetMTI("0200")
+	mb.AddField(2, m.PAN)
+	mb.AddField(3, strconv.FormatInt(m.ProcessingCode, 10))
+	mb.AddField(4, strconv.FormatInt(m.AmountTransaction, 10))
+	mb.AddField(7, strconv.FormatInt(m.TransmissionDateTime, 10))
+	mb.AddField(11, strconv.FormatInt(m.SystemTraceAuditNumber, 10))
+	if m.DateExpiration != nil {
+		mb.AddField(14, strconv.FormatInt(*m.DateExpiration, 10))
+	}
+	if m.Track2 != nil {
+		mb.AddField(35, *m.Track2)
+	}
+	mb.AddField(41, m.CardAcceptorTerminalIdentification)
+	mb.AddField(49, m.CurrencyCodeTransaction)
+	if m.PersonalIdentificationNumberData != nil {
+		mb.AddField(52, string(m.PersonalIdentificationNumberData))
+	}
+	return mb
+}
+
+// Unpack fills m from a parsed 0200 message.
+func (m *FinancialRequest) Unpack(p *iso8583.Parser) error {
+	if p.MTI != "0200" {
+		return fmt.Errorf("unexpected MTI %s, expected 0200", p.MTI)
+	}
+	if v, ok := p.Fields[2]; ok {
+		m.PAN = v
+	} else {
+		return fmt.Errorf("missing field 2")
+	}
+	if v, ok := p.Fields[3]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 3: %v", err)
+		}
+		m.ProcessingCode = n
+	} else {
+		return fmt.Errorf("missing field 3")
+	}
+	if v, ok := p.Fields[4]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 4: %v", err)
+		}
+		m.AmountTransaction = n
+	} else {
+		return fmt.Errorf("missing field 4")
+	}
+	if v, ok := p.Fields[7]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 7: %v", err)
+		}
+		m.TransmissionDateTime = n
+	} else {
+		return fmt.Errorf("missing field 7")
+	}
+	if v, ok := p.Fields[11]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 11: %v", err)
+		}
+		m.SystemTraceAuditNumber = n
+	} else {
+		return fmt.Errorf("missing field 11")
+	}
+	if v, ok := p.Fields[14]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 14: %v", err)
+		}
+		m.DateExpiration = &n
+	}
+	if v, ok := p.Fields[35]; ok {
+		m.Track2 = &v
+	}
+	if v, ok := p.Fields[41]; ok {
+		m.CardAcceptorTerminalIdentification = v
+	} else {
+		return fmt.Errorf("missing field 41")
+	}
+	if v, ok := p.Fields[49]; ok {
+		m.CurrencyCodeTransaction = v
+	} else {
+		return fmt.Errorf("missing field 49")
+	}
+	if v, ok := p.Fields[52]; ok {
+		m.PersonalIdentificationNumberData = []byte(v)
+	}
+	return nil
+}
+
+// FinancialResponse is the 0210 message.
+type FinancialResponse struct {
+	// PAN is field 2, Primary account number (PAN).
+	PAN string
+	// ProcessingCode is field 3, Processing code.
+	ProcessingCode int64
+	// AmountTransaction is field 4, Amount, transaction.
+	AmountTransaction int64
+	// TransmissionDateTime is field 7, Transmission date & time.
+	TransmissionDateTime int64
+	// SystemTraceAuditNumber is field 11, System trace audit number.
+	SystemTraceAuditNumber int64
+	// AuthorizationIdentificationResponse is field 38, Authorization identification response.
+	AuthorizationIdentificationResponse *string
+	// ResponseCode is field 39, Response code.
+	ResponseCode string
+	// CardAcceptorTerminalIdentification is field 41, Card acceptor terminal identification.
+	CardAcceptorTerminalIdentification string
+	// CurrencyCodeTransaction is field 49, Currency code, transaction.
+	CurrencyCodeTransaction string
+}
+
+// Pack creates a 0210 message builder from m using spec, or the
+// default spec when spec is nil.
+func (m *FinancialResponse) Pack(spec *iso8583.Spec) *iso8583.MessageBuilder {
+	mb := iso8583.NewISOWithSpec(spec)
+	mb.SetMTI("0210")
+	mb.AddField(2, m.PAN)
+	mb.AddField(3, strconv.FormatInt(m.ProcessingCode, 10))
+	mb.AddField(4, strconv.FormatInt(m.AmountTransaction, 10))
+	mb.AddField(7, strconv.FormatInt(m.TransmissionDateTime, 10))
+	mb.AddField(11, strconv.FormatInt(m.SystemTraceAuditNumber, 10))
+	if m.AuthorizationIdentificationResponse != nil {
+		mb.AddField(38, *m.AuthorizationIdentificationResponse)
+	}
+	mb.AddField(39, m.ResponseCode)
+	mb.AddField(41, m.CardAcceptorTerminalIdentification)
+	mb.AddField(49, m.CurrencyCodeTransaction)
+	return mb
+}
+
+// Unpack fills m from a parsed 0210 message.
+func (m *FinancialResponse) Unpack(p *iso8583.Parser) error {
+	if p.MTI != "0210" {
+		return fmt.Errorf("unexpected MTI %s, expected 0210", p.MTI)
+	}
+	if v, ok := p.Fields[2]; ok {
+		m.PAN = v
+	} else {
+		return fmt.Errorf("missing field 2")
+	}
+	if v, ok := p.Fields[3]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 3: %v", err)
+		}
+		m.ProcessingCode = n
+	} else {
+		return fmt.Errorf("missing field 3")
+	}
+	if v, ok := p.Fields[4]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 4: %v", err)
+		}
+		m.AmountTransaction = n
+	} else {
+		return fmt.Errorf("missing field 4")
+	}
+	if v, ok := p.Fields[7]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 7: %v", err)
+		}
+		m.TransmissionDateTime = n
+	} else {
+		return fmt.Errorf("missing field 7")
+	}
+	if v, ok := p.Fields[11]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field 11: %v", err)
+		}
+		m.SystemTraceAuditNumber = n
+	} else {
+		return fmt.Errorf("missing field 11")
+	}
+	if v, ok := p.Fields[38]; ok {
+		m.AuthorizationIdentificationResponse = &v
+	}
+	if v, ok := p.Fields[39]; ok {
+		m.ResponseCode = v
+	} else {
+		return fmt.Errorf("missing field 39")
+	}
+	if v, ok := p.Fields[41]; ok {
+		m.CardAcceptorTerminalIdentification = v
+	} else {
+		return fmt.Errorf("missing field 41")
+	}
+	if v, ok := p.Fields[49]; ok {
+		m.CurrencyCodeTransaction = v
+	} else {
+		return fmt.Errorf("missing field 49")
+	}
+	return nil
+}
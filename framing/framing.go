@@ -0,0 +1,120 @@
+// Package framing reads and writes ISO 8583 messages on a stream, such as a
+// TCP connection, where each message is preceded by a header carrying its
+// length and, for some networks, a TPDU.
+package framing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxLength is the maximum message length accepted by a Framer
+// without an explicit MaxLength.
+const DefaultMaxLength = 64 * 1024
+
+// ErrFrameTooLarge is returned when a frame exceeds the maximum length.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// Header is the decoded header of a frame.
+type Header struct {
+	Length int   // Length of the message, excluding the header
+	TPDU   *TPDU // TPDU, for codecs that carry one
+}
+
+// Frame is a message with its header.
+type Frame struct {
+	Header  Header
+	Message []byte
+}
+
+// Codec reads and writes the header in front of each message.
+type Codec interface {
+	// ReadHeader reads a header from r. Codecs that do not carry the
+	// message length return a Length of -1.
+	ReadHeader(r io.Reader) (Header, error)
+	// AppendHeader appends the encoded header to b.
+	AppendHeader(b []byte, h Header) ([]byte, error)
+}
+
+// Framer reads and writes complete frames with a header codec.
+type Framer struct {
+	Codec     Codec
+	MaxLength int // Maximum message length, DefaultMaxLength if zero
+}
+
+// New returns a Framer using the given header codec.
+func New(codec Codec) *Framer {
+	return &Framer{Codec: codec}
+}
+
+// ReadFrame reads the next frame from r. Without a length in the header,
+// the frame is the data returned by a single read.
+func (f *Framer) ReadFrame(r io.Reader) (Frame, error) {
+	h, err := f.Codec.ReadHeader(r)
+	if err != nil {
+		return Frame{}, err
+	}
+
+	if h.Length < 0 {
+		buf := make([]byte, f.maxLength())
+		n, err := r.Read(buf)
+		if n == 0 && err != nil {
+			return Frame{}, err
+		}
+		h.Length = n
+		return Frame{Header: h, Message: buf[:n]}, nil
+	}
+
+	if h.Length > f.maxLength() {
+		return Frame{}, fmt.Errorf("%w: %d bytes exceeds maximum %d", ErrFrameTooLarge, h.Length, f.maxLength())
+	}
+
+	msg := make([]byte, h.Length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return Frame{}, fmt.Errorf("error reading message: %w", err)
+	}
+
+	return Frame{Header: h, Message: msg}, nil
+}
+
+// WriteFrame writes the message with a header to w in a single write.
+// The header length is always set from the message.
+func (f *Framer) WriteFrame(w io.Writer, frame Frame) error {
+	if len(frame.Message) > f.maxLength() {
+		return fmt.Errorf("%w: %d bytes exceeds maximum %d", ErrFrameTooLarge, len(frame.Message), f.maxLength())
+	}
+
+	h := frame.Header
+	h.Length = len(frame.Message)
+
+	buf, err := f.Codec.AppendHeader(nil, h)
+	if err != nil {
+		return err
+	}
+	buf = append(buf, frame.Message...)
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// ReadMessage reads the next frame from r and returns its message.
+func (f *Framer) ReadMessage(r io.Reader) (string, error) {
+	frame, err := f.ReadFrame(r)
+	if err != nil {
+		return "", err
+	}
+	return string(frame.Message), nil
+}
+
+// WriteMessage writes a message built by MessageBuilder.Build to w.
+func (f *Framer) WriteMessage(w io.Writer, msg string) error {
+	return f.WriteFrame(w, Frame{Message: []byte(msg)})
+}
+
+func (f *Framer) maxLength() int {
+	if f.MaxLength > 0 {
+		return f.MaxLength
+	}
+	return DefaultMaxLength
+}
@@ -0,0 +1,107 @@
+package framing
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+)
+
+const testMessage = "08002200000000000000123456789012"
+
+func TestFramerRoundTrip(t *testing.T) {
+	codecs := map[string]Codec{
+		"Binary2":    Binary2,
+		"Binary4LE":  Binary4LE,
+		"ASCII4":     ASCII4,
+		"BCD4":       BCD4,
+		"TPDUHeader": TPDUHeader{Length: ASCII4},
+	}
+
+	for name, codec := range codecs {
+		f := New(codec)
+
+		var buf bytes.Buffer
+		if err := f.WriteMessage(&buf, testMessage); err != nil {
+			t.Errorf("%s: WriteMessage() error = %v", name, err)
+			continue
+		}
+		// Two frames back to back must be read separately
+		f.WriteMessage(&buf, testMessage+"X")
+
+		msg, err := f.ReadMessage(&buf)
+		if err != nil {
+			t.Errorf("%s: ReadMessage() error = %v", name, err)
+			continue
+		}
+		if msg != testMessage {
+			t.Errorf("%s: expected %s, got %s", name, testMessage, msg)
+		}
+
+		msg, _ = f.ReadMessage(&buf)
+		if msg != testMessage+"X" {
+			t.Errorf("%s: expected %sX, got %s", name, testMessage, msg)
+		}
+	}
+}
+
+func TestFramerNoHeader(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	f := New(NoHeader{})
+	go f.WriteMessage(client, testMessage)
+
+	msg, err := f.ReadMessage(server)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if msg != testMessage {
+		t.Errorf("Expected %s, got %s", testMessage, msg)
+	}
+}
+
+func TestFramerHeader(t *testing.T) {
+	tpdu, _ := ParseTPDU("6000030000")
+	f := New(TPDUHeader{})
+
+	var buf bytes.Buffer
+	if err := f.WriteFrame(&buf, Frame{Header: Header{TPDU: &tpdu}, Message: []byte(testMessage)}); err != nil {
+		t.Fatalf("WriteFrame() error = %v", err)
+	}
+
+	frame, err := f.ReadFrame(&buf)
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v", err)
+	}
+	if frame.Header.Length != len(testMessage) {
+		t.Errorf("Expected length %d, got %d", len(testMessage), frame.Header.Length)
+	}
+	if frame.Header.TPDU == nil || *frame.Header.TPDU != tpdu {
+		t.Errorf("Expected TPDU %s, got %v", tpdu, frame.Header.TPDU)
+	}
+}
+
+func TestFramerErrors(t *testing.T) {
+	f := &Framer{Codec: Binary2, MaxLength: 10}
+
+	var buf bytes.Buffer
+	if err := f.WriteMessage(&buf, testMessage); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("Expected ErrFrameTooLarge writing, got %v", err)
+	}
+
+	buf.Write([]byte{0x00, 0x20})
+	buf.WriteString(testMessage)
+	if _, err := f.ReadFrame(&buf); !errors.Is(err, ErrFrameTooLarge) {
+		t.Errorf("Expected ErrFrameTooLarge reading, got %v", err)
+	}
+
+	f.MaxLength = 0
+	buf.Reset()
+	buf.Write([]byte{0x00, 0x20})
+	buf.WriteString("0800")
+	if _, err := f.ReadFrame(&buf); err == nil {
+		t.Errorf("Expected error for truncated message")
+	}
+}
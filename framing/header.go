@@ -0,0 +1,159 @@
+package framing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Common header codecs.
+var (
+	Binary2   = BinaryLength{Size: 2, Order: binary.BigEndian}
+	Binary2LE = BinaryLength{Size: 2, Order: binary.LittleEndian}
+	Binary4   = BinaryLength{Size: 4, Order: binary.BigEndian}
+	Binary4LE = BinaryLength{Size: 4, Order: binary.LittleEndian}
+	ASCII4    = ASCIILength{Digits: 4}
+	BCD4      = BCDLength{Digits: 4}
+)
+
+// BinaryLength is a header with the message length as a 2 or 4 byte
+// unsigned integer.
+type BinaryLength struct {
+	Size  int // 2 or 4 bytes
+	Order binary.ByteOrder
+}
+
+// ReadHeader implements Codec.
+func (c BinaryLength) ReadHeader(r io.Reader) (Header, error) {
+	if c.Size != 2 && c.Size != 4 {
+		return Header{}, fmt.Errorf("unsupported binary length size %d", c.Size)
+	}
+
+	buf := make([]byte, c.Size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return Header{}, err
+	}
+
+	if c.Size == 2 {
+		return Header{Length: int(c.order().Uint16(buf))}, nil
+	}
+	return Header{Length: int(c.order().Uint32(buf))}, nil
+}
+
+// AppendHeader implements Codec.
+func (c BinaryLength) AppendHeader(b []byte, h Header) ([]byte, error) {
+	switch c.Size {
+	case 2:
+		if h.Length > 0xFFFF {
+			return nil, fmt.Errorf("length %d does not fit in 2 bytes", h.Length)
+		}
+		buf := make([]byte, 2)
+		c.order().PutUint16(buf, uint16(h.Length))
+		return append(b, buf...), nil
+	case 4:
+		buf := make([]byte, 4)
+		c.order().PutUint32(buf, uint32(h.Length))
+		return append(b, buf...), nil
+	default:
+		return nil, fmt.Errorf("unsupported binary length size %d", c.Size)
+	}
+}
+
+func (c BinaryLength) order() binary.ByteOrder {
+	if c.Order == nil {
+		return binary.BigEndian
+	}
+	return c.Order
+}
+
+// ASCIILength is a header with the message length as zero-padded decimal
+// digits.
+type ASCIILength struct {
+	Digits int
+}
+
+// ReadHeader implements Codec.
+func (c ASCIILength) ReadHeader(r io.Reader) (Header, error) {
+	buf := make([]byte, c.Digits)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return Header{}, err
+	}
+
+	length, err := strconv.Atoi(string(buf))
+	if err != nil || length < 0 {
+		return Header{}, fmt.Errorf("invalid ASCII length %q", buf)
+	}
+
+	return Header{Length: length}, nil
+}
+
+// AppendHeader implements Codec.
+func (c ASCIILength) AppendHeader(b []byte, h Header) ([]byte, error) {
+	length := fmt.Sprintf("%0*d", c.Digits, h.Length)
+	if len(length) > c.Digits {
+		return nil, fmt.Errorf("length %d does not fit in %d digits", h.Length, c.Digits)
+	}
+	return append(b, length...), nil
+}
+
+// BCDLength is a header with the message length as packed BCD digits,
+// two per byte.
+type BCDLength struct {
+	Digits int // An even number of digits
+}
+
+// ReadHeader implements Codec.
+func (c BCDLength) ReadHeader(r io.Reader) (Header, error) {
+	buf := make([]byte, (c.Digits+1)/2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return Header{}, err
+	}
+
+	length, err := decodeBCD(buf)
+	if err != nil {
+		return Header{}, fmt.Errorf("invalid BCD length: %v", err)
+	}
+
+	return Header{Length: length}, nil
+}
+
+// AppendHeader implements Codec.
+func (c BCDLength) AppendHeader(b []byte, h Header) ([]byte, error) {
+	digits := (c.Digits + 1) / 2 * 2
+	length := fmt.Sprintf("%0*d", digits, h.Length)
+	if len(length) > digits {
+		return nil, fmt.Errorf("length %d does not fit in %d digits", h.Length, c.Digits)
+	}
+
+	for i := 0; i < len(length); i += 2 {
+		b = append(b, (length[i]-'0')<<4|(length[i+1]-'0'))
+	}
+	return b, nil
+}
+
+// decodeBCD decodes packed BCD digits.
+func decodeBCD(buf []byte) (int, error) {
+	value := 0
+	for _, bt := range buf {
+		hi, lo := int(bt>>4), int(bt&0x0F)
+		if hi > 9 || lo > 9 {
+			return 0, fmt.Errorf("invalid BCD byte %02X", bt)
+		}
+		value = value*100 + hi*10 + lo
+	}
+	return value, nil
+}
+
+// NoHeader is a codec for messages sent without a header, one per read.
+type NoHeader struct{}
+
+// ReadHeader implements Codec.
+func (NoHeader) ReadHeader(r io.Reader) (Header, error) {
+	return Header{Length: -1}, nil
+}
+
+// AppendHeader implements Codec.
+func (NoHeader) AppendHeader(b []byte, h Header) ([]byte, error) {
+	return b, nil
+}
@@ -0,0 +1,60 @@
+package framing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHeaderCodecs(t *testing.T) {
+	tests := []struct {
+		name   string
+		codec  Codec
+		header string
+	}{
+		{"Binary2", Binary2, "012C"},
+		{"Binary2LE", Binary2LE, "2C01"},
+		{"Binary4", Binary4, "0000012C"},
+		{"Binary4LE", Binary4LE, "2C010000"},
+		{"ASCII4", ASCII4, hex.EncodeToString([]byte("0300"))},
+		{"BCD4", BCD4, "0300"},
+	}
+
+	for _, tt := range tests {
+		b, err := tt.codec.AppendHeader(nil, Header{Length: 300})
+		if err != nil {
+			t.Errorf("%s: AppendHeader() error = %v", tt.name, err)
+			continue
+		}
+		if got := hex.EncodeToString(b); !bytes.EqualFold([]byte(got), []byte(tt.header)) {
+			t.Errorf("%s: expected header %s, got %s", tt.name, tt.header, got)
+		}
+
+		h, err := tt.codec.ReadHeader(bytes.NewReader(b))
+		if err != nil {
+			t.Errorf("%s: ReadHeader() error = %v", tt.name, err)
+			continue
+		}
+		if h.Length != 300 {
+			t.Errorf("%s: expected length 300, got %d", tt.name, h.Length)
+		}
+	}
+}
+
+func TestHeaderCodecErrors(t *testing.T) {
+	if _, err := Binary2.AppendHeader(nil, Header{Length: 70000}); err == nil {
+		t.Errorf("Expected error for length exceeding 2 bytes")
+	}
+	if _, err := ASCII4.AppendHeader(nil, Header{Length: 10000}); err == nil {
+		t.Errorf("Expected error for length exceeding 4 digits")
+	}
+	if _, err := ASCII4.ReadHeader(bytes.NewReader([]byte("01A0"))); err == nil {
+		t.Errorf("Expected error for non-numeric ASCII length")
+	}
+	if _, err := BCD4.ReadHeader(bytes.NewReader([]byte{0x0A, 0x00})); err == nil {
+		t.Errorf("Expected error for invalid BCD length")
+	}
+	if _, err := Binary2.ReadHeader(bytes.NewReader([]byte{0x01})); err == nil {
+		t.Errorf("Expected error for truncated header")
+	}
+}
@@ -0,0 +1,102 @@
+package framing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// TPDUSize is the size of a TPDU in bytes.
+const TPDUSize = 5
+
+// TPDU is the Transport Protocol Data Unit sent in front of messages on
+// many POS networks: an ID followed by the destination and source
+// addresses, each holding a network international identifier (NII) as BCD.
+type TPDU struct {
+	ID          byte
+	Destination uint16
+	Source      uint16
+}
+
+// ParseTPDU parses a TPDU from its hexadecimal form, e.g. "6000010000".
+func ParseTPDU(s string) (TPDU, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil || len(buf) != TPDUSize {
+		return TPDU{}, fmt.Errorf("invalid TPDU %q", s)
+	}
+	return decodeTPDU(buf), nil
+}
+
+// String returns the TPDU in hexadecimal form.
+func (t TPDU) String() string {
+	return fmt.Sprintf("%02X%04X%04X", t.ID, t.Destination, t.Source)
+}
+
+// Reply returns the TPDU for a response, with the addresses swapped.
+func (t TPDU) Reply() TPDU {
+	return TPDU{ID: t.ID, Destination: t.Source, Source: t.Destination}
+}
+
+// NII returns the network international identifier of the destination.
+func (t TPDU) NII() (int, error) {
+	return decodeBCD([]byte{byte(t.Destination >> 8), byte(t.Destination)})
+}
+
+func decodeTPDU(buf []byte) TPDU {
+	return TPDU{
+		ID:          buf[0],
+		Destination: uint16(buf[1])<<8 | uint16(buf[2]),
+		Source:      uint16(buf[3])<<8 | uint16(buf[4]),
+	}
+}
+
+func (t TPDU) appendTo(b []byte) []byte {
+	return append(b, t.ID, byte(t.Destination>>8), byte(t.Destination), byte(t.Source>>8), byte(t.Source))
+}
+
+// TPDUHeader is a header made of a length, covering the TPDU and the
+// message, followed by a TPDU.
+type TPDUHeader struct {
+	Length Codec // Length codec, Binary2 if nil
+	TPDU   TPDU  // TPDU written when the frame header has none
+}
+
+// ReadHeader implements Codec.
+func (c TPDUHeader) ReadHeader(r io.Reader) (Header, error) {
+	h, err := c.length().ReadHeader(r)
+	if err != nil {
+		return Header{}, err
+	}
+	if h.Length < TPDUSize {
+		return Header{}, fmt.Errorf("length %d shorter than TPDU", h.Length)
+	}
+
+	buf := make([]byte, TPDUSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return Header{}, fmt.Errorf("error reading TPDU: %w", err)
+	}
+
+	tpdu := decodeTPDU(buf)
+	return Header{Length: h.Length - TPDUSize, TPDU: &tpdu}, nil
+}
+
+// AppendHeader implements Codec.
+func (c TPDUHeader) AppendHeader(b []byte, h Header) ([]byte, error) {
+	tpdu := c.TPDU
+	if h.TPDU != nil {
+		tpdu = *h.TPDU
+	}
+
+	b, err := c.length().AppendHeader(b, Header{Length: h.Length + TPDUSize})
+	if err != nil {
+		return nil, err
+	}
+	return tpdu.appendTo(b), nil
+}
+
+func (c TPDUHeader) length() Codec {
+	if c.Length == nil {
+		return Binary2
+	}
+	return c.Length
+}
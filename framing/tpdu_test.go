@@ -0,0 +1,58 @@
+package framing
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTPDU(t *testing.T) {
+	tpdu, err := ParseTPDU("6000030000")
+	if err != nil {
+		t.Fatalf("ParseTPDU() error = %v", err)
+	}
+	if tpdu.ID != 0x60 || tpdu.Destination != 0x0003 || tpdu.Source != 0 {
+		t.Errorf("Unexpected TPDU %+v", tpdu)
+	}
+	if nii, err := tpdu.NII(); err != nil || nii != 3 {
+		t.Errorf("Expected NII 3, got %d (error = %v)", nii, err)
+	}
+	if reply := tpdu.Reply().String(); reply != "6000000003" {
+		t.Errorf("Expected reply TPDU 6000000003, got %s", reply)
+	}
+
+	if _, err := ParseTPDU("600003"); err == nil {
+		t.Errorf("Expected error for short TPDU")
+	}
+}
+
+func TestTPDUHeader(t *testing.T) {
+	tpdu, _ := ParseTPDU("6000030000")
+	codec := TPDUHeader{TPDU: tpdu}
+
+	b, err := codec.AppendHeader(nil, Header{Length: 10})
+	if err != nil {
+		t.Fatalf("AppendHeader() error = %v", err)
+	}
+	expected := []byte{0x00, 0x0F, 0x60, 0x00, 0x03, 0x00, 0x00}
+	if !bytes.Equal(b, expected) {
+		t.Errorf("Expected header %X, got %X", expected, b)
+	}
+
+	h, err := codec.ReadHeader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadHeader() error = %v", err)
+	}
+	if h.Length != 10 || h.TPDU == nil || *h.TPDU != tpdu {
+		t.Errorf("Unexpected header %+v", h)
+	}
+
+	reply := tpdu.Reply()
+	b, _ = codec.AppendHeader(nil, Header{Length: 10, TPDU: &reply})
+	if !bytes.Equal(b[2:], []byte{0x60, 0x00, 0x00, 0x00, 0x03}) {
+		t.Errorf("Expected header TPDU to override codec TPDU, got %X", b[2:])
+	}
+
+	if _, err := codec.ReadHeader(bytes.NewReader([]byte{0x00, 0x03, 0x60, 0x00, 0x03})); err == nil {
+		t.Errorf("Expected error for length shorter than TPDU")
+	}
+}
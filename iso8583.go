@@ -1,48 +1,55 @@
 package iso8583
 
+import (
+	"sync"
+)
+
 // Iso8583 provides methods to parse and build ISO 8583 messages.
+// It is safe for concurrent use: every call to CreateISO and Parse
+// returns an independent message.
 type Iso8583 struct {
-	parser *Parser
-	build  *MessageBuilder
+	spec *Spec
+
+	mu   sync.Mutex
+	last *Parser
 }
 
 // New initializes a new ISO 8583 message parser and builder.
 func New() *Iso8583 {
+	return NewWithSpec(DefaultSpec)
+}
+
+// NewWithSpec initializes a new ISO 8583 message parser and builder
+// using the given spec.
+func NewWithSpec(spec *Spec) *Iso8583 {
 	return &Iso8583{
-		parser: NewParser(),
-		build:  NewISO(),
+		spec: spec,
 	}
 }
 
 // Parse decodes an ISO 8583 message.
 func (i *Iso8583) Parse(raw string) (*Parser, error) {
-	msg, err := i.parser.Parse(raw)
-	if err != nil {
-		return msg, err
-	}
+	msg, err := NewParserWithSpec(i.spec).Parse(raw)
+
+	i.mu.Lock()
+	i.last = msg
+	i.mu.Unlock()
 
-	return msg, nil
+	return msg, err
 }
 
 // CreateISO initializes a new ISO 8583 message builder with an MTI.
 func (i *Iso8583) CreateISO(mti string) *MessageBuilder {
-	// check MTI is filled, if true, reset it
-	if len(i.build.MTI) > 0 {
-		i.build.MTI = ""
-	}
-
-	// check if fields is filled, if true, reset it
-	if i.build.Fields != nil {
-		i.build.Fields = make(map[int]string)
-	}
-
-	// set MTI
-	i.build.MTI = mti
-
-	return i.build
+	return NewISOWithSpec(i.spec).SetMTI(mti)
 }
 
-// LogFields prints the fields of the ISO 8583 message.
-func (i Iso8583) LogFields() {
-	i.parser.LogFields()
+// LogFields prints the fields of the last parsed ISO 8583 message.
+func (i *Iso8583) LogFields() {
+	i.mu.Lock()
+	last := i.last
+	i.mu.Unlock()
+
+	if last != nil {
+		last.LogFields()
+	}
 }
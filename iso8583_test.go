@@ -0,0 +1,69 @@
+package iso8583
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestIso8583Concurrent(t *testing.T) {
+	i := New()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+
+	for n := 0; n < 50; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			stan := fmt.Sprintf("%06d", n)
+			amount := fmt.Sprintf("%012d", n*100)
+
+			msg := i.CreateISO("0200")
+			msg.AddField(3, "000000")
+			msg.AddField(4, amount)
+			msg.AddField(11, stan)
+
+			raw, err := msg.Build()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			parsed, err := i.Parse(raw)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if parsed.Fields[11] != stan || parsed.Fields[4] != amount {
+				errs <- fmt.Errorf("message %d: got STAN %s amount %s", n, parsed.Fields[11], parsed.Fields[4])
+			}
+		}(n)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestIso8583CreateISOIndependent(t *testing.T) {
+	i := New()
+
+	a := i.CreateISO("0200").AddField(11, "000001")
+	b := i.CreateISO("0800")
+
+	if a == b {
+		t.Fatalf("Expected independent builders")
+	}
+	if a.MTI != "0200" || a.Fields[11] != "000001" {
+		t.Errorf("Expected first builder to be unchanged, got %s %v", a.MTI, a.Fields)
+	}
+	if len(b.Fields) != 0 {
+		t.Errorf("Expected second builder to be empty, got %v", b.Fields)
+	}
+}
@@ -11,18 +11,34 @@ type Parser struct {
 	MTI          string
 	Bitmap       string
 	Fields       map[int]string
+	Subfields    map[string]string
 	ActiveFields []int
 	HasSecBitmap bool
 	LastField    int
+
+	spec *Spec
 }
 
 // NewParser initializes a new ISO 8583 message parser.
 func NewParser() *Parser {
+	return NewParserWithSpec(DefaultSpec)
+}
+
+// NewParserWithSpec initializes a new ISO 8583 message parser
+// that decodes fields according to the given spec.
+func NewParserWithSpec(spec *Spec) *Parser {
 	return &Parser{
-		Fields: make(map[int]string),
+		Fields:    make(map[int]string),
+		Subfields: make(map[string]string),
+		spec:      spec,
 	}
 }
 
+// Spec returns the spec used by the parser.
+func (m *Parser) Spec() *Spec {
+	return specOrDefault(m.spec)
+}
+
 // Parse decodes an ISO 8583 message.
 func (m *Parser) Parse(raw string) (*Parser, error) {
 	if len(raw) < 4 {
@@ -53,32 +69,18 @@ func (m *Parser) Parse(raw string) (*Parser, error) {
 // ParseBitmap converts a hexadecimal bitmap string to a binary string and identifies active fields.
 func (m *Parser) ParseBitmap(rawBitmap string) error {
 	m.Bitmap = ""
-	bitmapHex := rawBitmap[4:20] // Primary bitmap
-	fmt.Println("Bitmap1: ", bitmapHex)
-
-	bitmap, err := hex.DecodeString(bitmapHex)
-	if err != nil {
-		return fmt.Errorf("failed to decode primary bitmap: %v", err)
+	if len(rawBitmap) < 20 {
+		return fmt.Errorf("raw data too short to contain bitmap")
 	}
-
-	for _, b := range bitmap {
-		m.Bitmap += fmt.Sprintf("%08b", b)
+	bitmap, err := decodeBitmap(rawBitmap[4:])
+	if err != nil {
+		return err
 	}
+	m.Bitmap = bitmap
 
-	if m.Bitmap[0] == '1' {
+	if len(m.Bitmap) > 64 {
 		// Secondary bitmap is present
 		m.HasSecBitmap = true
-		secondaryBitmapHex := rawBitmap[20:36]
-		fmt.Println("Bitmap2: ", secondaryBitmapHex)
-
-		secondaryBitmap, err := hex.DecodeString(secondaryBitmapHex)
-		if err != nil {
-			return fmt.Errorf("failed to decode secondary bitmap: %v", err)
-		}
-
-		for _, b := range secondaryBitmap {
-			m.Bitmap += fmt.Sprintf("%08b", b)
-		}
 	}
 
 	for i, bit := range m.Bitmap {
@@ -94,15 +96,12 @@ func (m *Parser) ParseBitmap(rawBitmap string) error {
 
 // ParseFields parses all fields indicated by the bitmap.
 func (m *Parser) ParseFields(rawData string) error {
-
-	fmt.Println("ActiveFields: ", m.ActiveFields)
-
 	for _, fieldNum := range m.ActiveFields {
 		if fieldNum == 1 {
 			continue // Skip the bitmap field itself
 		}
 
-		elem, exists := dataElem[fieldNum]
+		elem, exists := m.Spec().Element(fieldNum)
 		if !exists {
 			return fmt.Errorf("no parser for field %d", fieldNum)
 		}
@@ -112,40 +111,91 @@ func (m *Parser) ParseFields(rawData string) error {
 
 		switch elem.LenType {
 		case Fixed:
-			if len(rawData) < elem.MaxLen {
+			width := elem.Encoding.width(elem.MaxLen)
+			if len(rawData) < width {
 				return fmt.Errorf("not enough data for fixed-length field %d", fieldNum)
 			}
-			fieldValue = rawData[:elem.MaxLen]
-			remaining = rawData[elem.MaxLen:]
+			fieldValue = rawData[:width]
+			remaining = rawData[width:]
 
 		case LLVAR:
-			fieldValue, remaining, err = m.parseLLVAR(rawData, fieldNum)
+			fieldValue, remaining, err = m.parseLLVAR(rawData, fieldNum, elem.Encoding)
 			if err != nil {
 				return fmt.Errorf("failed to parse LLVAR field %d: %v", fieldNum, err)
 			}
 
 		case LLLVAR:
-			fieldValue, remaining, err = m.parseLLLVAR(rawData, fieldNum)
+			fieldValue, remaining, err = m.parseLLLVAR(rawData, fieldNum, elem.Encoding)
 			if err != nil {
 				return fmt.Errorf("failed to parse LLLVAR field %d: %v", fieldNum, err)
 			}
 		}
 
+		fieldValue, err = elem.Encoding.decode(fieldValue)
+		if err != nil {
+			return fmt.Errorf("failed to decode field %d: %v", fieldNum, err)
+		}
+
 		// Update the field value in the message
 		m.Fields[fieldNum] = fieldValue
 
+		// Decompose composite fields into their subfields
+		if elem.composite() {
+			if m.Subfields == nil {
+				m.Subfields = make(map[string]string)
+			}
+			// Fields that cannot be decomposed keep their raw value only
+			subfields := make(map[string]string)
+			if err := decomposeElement(strconv.Itoa(fieldNum), fieldValue, elem, subfields); err == nil {
+				for path, value := range subfields {
+					m.Subfields[path] = value
+				}
+			}
+		}
+
 		// Update rawData to the remaining part for the next field parsing
 		rawData = remaining
-		fmt.Printf("Element%d - %v\n", fieldNum, elem)
-		fmt.Printf("Field%d - %s\n", fieldNum, fieldValue)
-		fmt.Println("Remaining for next:", remaining)
-		fmt.Println("-----------------")
 	}
 
 	return nil
 }
 
-func (m *Parser) parseLLVAR(input string, fieldNum int) (value string, remaining string, err error) {
+// decodeBitmap converts a hexadecimal primary bitmap, followed by a
+// secondary bitmap when its first bit is set, to a binary string.
+func decodeBitmap(raw string) (string, error) {
+	if len(raw) < 16 {
+		return "", fmt.Errorf("failed to decode primary bitmap: too short")
+	}
+
+	primaryBitmap, err := hex.DecodeString(raw[:16])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode primary bitmap: %v", err)
+	}
+
+	var bitmap string
+	for _, b := range primaryBitmap {
+		bitmap += fmt.Sprintf("%08b", b)
+	}
+
+	if bitmap[0] == '1' {
+		if len(raw) < 32 {
+			return "", fmt.Errorf("failed to decode secondary bitmap: too short")
+		}
+
+		secondaryBitmap, err := hex.DecodeString(raw[16:32])
+		if err != nil {
+			return "", fmt.Errorf("failed to decode secondary bitmap: %v", err)
+		}
+
+		for _, b := range secondaryBitmap {
+			bitmap += fmt.Sprintf("%08b", b)
+		}
+	}
+
+	return bitmap, nil
+}
+
+func (m *Parser) parseLLVAR(input string, fieldNum int, enc Encoding) (value string, remaining string, err error) {
 	if len(input) < 2 {
 		return "", "", fmt.Errorf("input too short for LLVAR length indicator")
 	}
@@ -156,7 +206,8 @@ func (m *Parser) parseLLVAR(input string, fieldNum int) (value string, remaining
 		return "", "", fmt.Errorf("failed to parse LLVAR length indicator: %v", err)
 	}
 
-	fmt.Println("parseLLVAR Length: ", length)
+	// Convert the value length to message characters
+	length = enc.width(length)
 
 	// Adjust length if it exceeds the input's remaining length
 	if length > len(input)-2 {
@@ -175,7 +226,7 @@ func (m *Parser) parseLLVAR(input string, fieldNum int) (value string, remaining
 	return value, remaining, nil
 }
 
-func (m *Parser) parseLLLVAR(input string, fieldNum int) (value string, remaining string, err error) {
+func (m *Parser) parseLLLVAR(input string, fieldNum int, enc Encoding) (value string, remaining string, err error) {
 	if len(input) < 3 {
 		return "", "", fmt.Errorf("input too short for LLLVAR length indicator")
 	}
@@ -186,7 +237,8 @@ func (m *Parser) parseLLLVAR(input string, fieldNum int) (value string, remainin
 		return "", "", fmt.Errorf("failed to parse LLLVAR length indicator: %v", err)
 	}
 
-	fmt.Println("parseLLLVAR Length: ", length)
+	// Convert the value length to message characters
+	length = enc.width(length)
 
 	// Adjust length if it exceeds the input's remaining length
 	if length > len(input)-3 {
@@ -209,13 +261,14 @@ func (m *Parser) resetFields() {
 	m.MTI = ""
 	m.Bitmap = ""
 	m.Fields = make(map[int]string)
+	m.Subfields = make(map[string]string)
 	m.ActiveFields = []int{}
 	m.HasSecBitmap = false
 	m.LastField = 0
 }
 
 func (m *Parser) fieldsAreEmpty() bool {
-	return m.MTI == "" && m.Bitmap == "" && len(m.Fields) == 0 && len(m.ActiveFields) == 0 && !m.HasSecBitmap
+	return m.MTI == "" && m.Bitmap == "" && len(m.Fields) == 0 && len(m.Subfields) == 0 && len(m.ActiveFields) == 0 && !m.HasSecBitmap
 }
 
 func (m *Parser) LogFields() {
@@ -1,6 +1,8 @@
 package iso8583
 
 import (
+	"io"
+	"os"
 	"testing"
 )
 
@@ -81,3 +83,39 @@ func TestCreateISO(t *testing.T) {
 		t.Errorf("Expected ISO message = %s, got %s", expectedISO, isoMessage)
 	}
 }
+
+func TestBuildVariableFieldOverMaxLen(t *testing.T) {
+	msg := NewISO()
+	msg.SetMTI("0200")
+	msg.AddField(2, "40000012345678901234")
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if raw[20:] != "2040000012345678901234" {
+		t.Errorf("Field 2: expected 2040000012345678901234, got %s", raw[20:])
+	}
+}
+
+func TestParseWritesNoOutput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	_, err = NewParser().Parse("0200" + "6000000000000000" + "164000001234567890" + "000000")
+	os.Stdout = stdout
+	w.Close()
+
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	output, _ := io.ReadAll(r)
+	if len(output) != 0 {
+		t.Errorf("Expected no output from Parse, got %q", output)
+	}
+}
@@ -0,0 +1,366 @@
+// Package network sends and receives ISO 8583 messages over TCP
+// connections, using the framing package to delimit messages.
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"iso8583"
+	"iso8583/framing"
+)
+
+var (
+	// ErrClosed is returned when sending on a closed client.
+	ErrClosed = errors.New("client closed")
+	// ErrDuplicateKey is returned when a request has the same key as a
+	// request still waiting for its response.
+	ErrDuplicateKey = errors.New("duplicate request key")
+)
+
+// KeyFunc returns the key matching a response to its request.
+type KeyFunc func(msg *iso8583.Parser) string
+
+// FieldsKey returns a KeyFunc joining the values of the given fields.
+func FieldsKey(fields ...int) KeyFunc {
+	return func(msg *iso8583.Parser) string {
+		values := make([]string, len(fields))
+		for i, fieldNum := range fields {
+			values[i] = msg.Fields[fieldNum]
+		}
+		return strings.Join(values, "|")
+	}
+}
+
+// DefaultKey matches responses by STAN (11) and terminal ID (41).
+var DefaultKey = FieldsKey(11, 41)
+
+// ClientConfig configures a Client.
+type ClientConfig struct {
+	// Spec used to parse messages, DefaultSpec if nil.
+	Spec *iso8583.Spec
+	// Framer used to read and write messages, a 2 byte binary length
+	// header if nil.
+	Framer *framing.Framer
+	// Key matches responses to requests, DefaultKey if nil.
+	Key KeyFunc
+	// Unmatched receives requests sent by the host and responses that
+	// match no pending request, e.g. after their request timed out.
+	// It is called from the read loop and must not block.
+	Unmatched func(msg *iso8583.Parser)
+	// Error receives errors reading messages that could not be parsed
+	// and errors from automatic network management.
+	Error func(err error)
+
+	// NetworkManagement answers 0800 requests from the host with 0810
+	// instead of passing them to Unmatched.
+	NetworkManagement bool
+	// SignOn signs on after connecting and refuses requests other than
+	// network management with ErrNotSignedOn until signed on.
+	SignOn bool
+	// EchoInterval sends an echo test whenever the connection is idle
+	// for the interval. Zero disables echo tests.
+	EchoInterval time.Duration
+	// NetworkTimeout is the time to wait for network management
+	// responses, DefaultNetworkTimeout if zero.
+	NetworkTimeout time.Duration
+	// NetworkFields are added to network management requests, e.g. the
+	// acquiring institution ID (32).
+	NetworkFields map[int]string
+
+	// TLS configures TLS for connections made by Dial. See TLSOptions.
+	TLS *tls.Config
+
+	// Limits restricts the requests sent with Send, rejecting those over
+	// the limits with ErrLimited. Network management requests are not
+	// limited.
+	Limits Limits
+}
+
+func (c ClientConfig) withDefaults() ClientConfig {
+	if c.Spec == nil {
+		c.Spec = iso8583.DefaultSpec
+	}
+	if c.Framer == nil {
+		c.Framer = framing.New(framing.Binary2)
+	}
+	if c.Key == nil {
+		c.Key = DefaultKey
+	}
+	return c
+}
+
+// Client sends requests on a single connection and matches the responses,
+// which may arrive in any order, to the waiting requests. It is safe for
+// concurrent use.
+type Client struct {
+	conn    net.Conn
+	config  ClientConfig
+	limiter *limiter
+
+	writeMu  sync.Mutex
+	stan     atomic.Uint32
+	activity atomic.Int64 // Time of the last read or write, in nanoseconds
+
+	mu         sync.Mutex
+	pending    map[string]chan *iso8583.Parser
+	signedOn   bool
+	signedOnCh chan struct{} // Closed when signed on
+	err        error
+	done       chan struct{}
+	stopped    chan struct{}
+}
+
+// Dial connects to the host at addr, over TLS if configured, and returns
+// a client for the connection. With SignOn, it also waits for the
+// sign-on until ctx is done.
+func Dial(ctx context.Context, addr string, config ClientConfig) (*Client, error) {
+	conn, err := dialConn(ctx, addr, config.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return connect(ctx, conn, config)
+}
+
+// dialConn connects to addr, over TLS if tlsConfig is set.
+func dialConn(ctx context.Context, addr string, tlsConfig *tls.Config) (net.Conn, error) {
+	if tlsConfig != nil {
+		d := tls.Dialer{Config: tlsConfig}
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+// connect returns a client for conn, waiting for the sign-on if
+// configured.
+func connect(ctx context.Context, conn net.Conn, config ClientConfig) (*Client, error) {
+	c := NewClient(conn, config)
+	if config.SignOn {
+		if err := c.WaitSignedOn(ctx); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// NewClient returns a client sending and receiving messages on conn.
+// Signing on and echo tests, if configured, run in the background.
+func NewClient(conn net.Conn, config ClientConfig) *Client {
+	c := &Client{
+		conn:       conn,
+		config:     config.withDefaults(),
+		limiter:    newLimiter(config.Limits),
+		pending:    make(map[string]chan *iso8583.Parser),
+		signedOnCh: make(chan struct{}),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	c.activity.Store(time.Now().UnixNano())
+
+	go c.readLoop()
+	go c.manage()
+
+	return c
+}
+
+// Send sends a request and waits for its response until ctx is done.
+func (c *Client) Send(ctx context.Context, request *iso8583.MessageBuilder) (*iso8583.Parser, error) {
+	if c.config.SignOn && !isNetworkManagement(request.MTI) && !c.SignedOn() {
+		return nil, notSentError{ErrNotSignedOn}
+	}
+
+	if !isNetworkManagement(request.MTI) {
+		release, err := c.limiter.acquire(ctx)
+		if err != nil {
+			return nil, notSentError{err}
+		}
+		defer release()
+	}
+
+	return c.send(ctx, request)
+}
+
+// send sends a request and waits for its response.
+func (c *Client) send(ctx context.Context, request *iso8583.MessageBuilder) (*iso8583.Parser, error) {
+	raw, err := request.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// The key is taken from the request as sent, with fixed fields padded
+	msg, err := iso8583.NewParserWithSpec(request.Spec()).Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	key := c.config.Key(msg)
+
+	ch := make(chan *iso8583.Parser, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, notSentError{c.err}
+	}
+	if _, ok := c.pending[key]; ok {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w %s", ErrDuplicateKey, key)
+	}
+	c.pending[key] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(ctx, raw); err != nil {
+		return nil, notSentError{err}
+	}
+
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, c.Err()
+	}
+}
+
+// write writes a message, giving up at the deadline of ctx.
+func (c *Client) write(ctx context.Context, raw string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	deadline, _ := ctx.Deadline()
+	c.conn.SetWriteDeadline(deadline)
+
+	if err := c.config.Framer.WriteMessage(c.conn, raw); err != nil {
+		c.shutdown(fmt.Errorf("error writing message: %w", err))
+		return err
+	}
+	c.activity.Store(time.Now().UnixNano())
+
+	return nil
+}
+
+// readLoop reads messages until the connection fails or is closed.
+func (c *Client) readLoop() {
+	defer close(c.stopped)
+
+	for {
+		raw, err := c.config.Framer.ReadMessage(c.conn)
+		if err != nil {
+			c.shutdown(fmt.Errorf("error reading message: %w", err))
+			return
+		}
+		c.activity.Store(time.Now().UnixNano())
+
+		msg, err := iso8583.NewParserWithSpec(c.config.Spec).Parse(raw)
+		if err != nil {
+			c.error(fmt.Errorf("error parsing message: %w", err))
+			continue
+		}
+
+		if isResponse(msg.MTI) && c.deliver(msg) {
+			continue
+		}
+		if c.config.NetworkManagement && !isResponse(msg.MTI) && isNetworkManagement(msg.MTI) {
+			go c.answerNetworkRequest(msg)
+			continue
+		}
+		if c.config.Unmatched != nil {
+			c.config.Unmatched(msg)
+		}
+	}
+}
+
+// deliver passes a response to the request waiting for it.
+func (c *Client) deliver(msg *iso8583.Parser) bool {
+	key := c.config.Key(msg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.pending[key]
+	if !ok {
+		return false
+	}
+	delete(c.pending, key)
+	ch <- msg
+
+	return true
+}
+
+// shutdown stops the client with the given reason, failing all pending
+// requests.
+func (c *Client) shutdown(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+	c.conn.Close()
+}
+
+// Close closes the connection, failing all pending requests with
+// ErrClosed.
+func (c *Client) Close() error {
+	c.shutdown(ErrClosed)
+	<-c.stopped
+	return nil
+}
+
+// Done returns a channel closed when the client stops.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason the client stopped, or nil while it is running.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// notSentError is an error for a request that was not sent, which can
+// safely be sent on another connection.
+type notSentError struct {
+	err error
+}
+
+func (e notSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e notSentError) Unwrap() error {
+	return e.err
+}
+
+func (c *Client) error(err error) {
+	if c.config.Error != nil {
+		c.config.Error(err)
+	}
+}
+
+// isResponse reports whether the MTI is a response or acknowledgement.
+func isResponse(mti string) bool {
+	return len(mti) == 4 && mti[2] >= '0' && mti[2] <= '9' && (mti[2]-'0')%2 != 0
+}
@@ -0,0 +1,254 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"iso8583"
+	"iso8583/framing"
+)
+
+// newTestRequest creates a 0200 request with the given STAN.
+func newTestRequest(stan string) *iso8583.MessageBuilder {
+	msg := iso8583.NewISO()
+	msg.SetMTI("0200")
+	msg.AddField(3, "000000")
+	msg.AddField(4, "000000001000")
+	msg.AddField(11, stan)
+	msg.AddField(41, "TERM0001")
+	return msg
+}
+
+// readRequests reads n requests from the host side of a connection.
+func readRequests(t *testing.T, conn net.Conn, n int) []*iso8583.Parser {
+	f := framing.New(framing.Binary2)
+
+	var requests []*iso8583.Parser
+	for i := 0; i < n; i++ {
+		raw, err := f.ReadMessage(conn)
+		if err != nil {
+			t.Errorf("ReadMessage() error = %v", err)
+			return requests
+		}
+		msg, err := iso8583.NewParser().Parse(raw)
+		if err != nil {
+			t.Errorf("Parse() error = %v", err)
+			return requests
+		}
+		requests = append(requests, msg)
+	}
+	return requests
+}
+
+// respond writes an approved response to a request.
+func respond(t *testing.T, conn net.Conn, request *iso8583.Parser) {
+	response, err := iso8583.NewResponse(request)
+	if err != nil {
+		t.Errorf("NewResponse() error = %v", err)
+		return
+	}
+	response.AddField(39, "00")
+
+	raw, err := response.Build()
+	if err != nil {
+		t.Errorf("Build() error = %v", err)
+		return
+	}
+	if err := framing.New(framing.Binary2).WriteMessage(conn, raw); err != nil {
+		t.Errorf("WriteMessage() error = %v", err)
+	}
+}
+
+func TestClientOutOfOrderResponses(t *testing.T) {
+	clientConn, hostConn := net.Pipe()
+	defer hostConn.Close()
+
+	client := NewClient(clientConn, ClientConfig{})
+	defer client.Close()
+
+	const n = 5
+	go func() {
+		requests := readRequests(t, hostConn, n)
+		for i := len(requests) - 1; i >= 0; i-- {
+			respond(t, hostConn, requests[i])
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+		go func(stan string) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			response, err := client.Send(ctx, newTestRequest(stan))
+			if err != nil {
+				t.Errorf("Send(%s) error = %v", stan, err)
+				return
+			}
+			if response.MTI != "0210" || response.Fields[11] != stan {
+				t.Errorf("Send(%s): expected 0210 with STAN %s, got %s with STAN %s", stan, stan, response.MTI, response.Fields[11])
+			}
+		}(fmt.Sprintf("%06d", i))
+	}
+	wg.Wait()
+}
+
+func TestClientTimeoutAndUnmatched(t *testing.T) {
+	clientConn, hostConn := net.Pipe()
+	defer hostConn.Close()
+
+	unmatched := make(chan *iso8583.Parser, 2)
+	client := NewClient(clientConn, ClientConfig{
+		Unmatched: func(msg *iso8583.Parser) { unmatched <- msg },
+	})
+	defer client.Close()
+
+	requests := make(chan *iso8583.Parser, 1)
+	go func() {
+		for _, request := range readRequests(t, hostConn, 1) {
+			requests <- request
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.Send(ctx, newTestRequest("000001")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+
+	// The late response is delivered as unmatched
+	respond(t, hostConn, <-requests)
+
+	select {
+	case msg := <-unmatched:
+		if msg.MTI != "0210" {
+			t.Errorf("Expected unmatched 0210, got %s", msg.MTI)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected late response to be delivered as unmatched")
+	}
+
+	// Requests from the host are delivered as unmatched
+	raw, _ := newTestRequest("000002").SetMTI("0800").Build()
+	framing.New(framing.Binary2).WriteMessage(hostConn, raw)
+
+	select {
+	case msg := <-unmatched:
+		if msg.MTI != "0800" {
+			t.Errorf("Expected unsolicited 0800, got %s", msg.MTI)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected unsolicited request to be delivered as unmatched")
+	}
+}
+
+func TestClientDuplicateKey(t *testing.T) {
+	clientConn, hostConn := net.Pipe()
+	defer hostConn.Close()
+
+	client := NewClient(clientConn, ClientConfig{})
+	defer client.Close()
+
+	go readRequests(t, hostConn, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sent := make(chan error, 1)
+	go func() {
+		_, err := client.Send(ctx, newTestRequest("000001"))
+		sent <- err
+	}()
+
+	// Wait for the first request to be pending
+	deadline := time.Now().Add(time.Second)
+	for {
+		client.mu.Lock()
+		pending := len(client.pending)
+		client.mu.Unlock()
+		if pending == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := client.Send(context.Background(), newTestRequest("000001")); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Expected ErrDuplicateKey, got %v", err)
+	}
+
+	cancel()
+	if err := <-sent; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Canceled, got %v", err)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	clientConn, hostConn := net.Pipe()
+	defer hostConn.Close()
+
+	client := NewClient(clientConn, ClientConfig{})
+
+	go readRequests(t, hostConn, 1)
+
+	sent := make(chan error, 1)
+	go func() {
+		_, err := client.Send(context.Background(), newTestRequest("000001"))
+		sent <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	client.Close()
+
+	select {
+	case err := <-sent:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Expected ErrClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected pending request to fail on close")
+	}
+
+	if _, err := client.Send(context.Background(), newTestRequest("000002")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed after close, got %v", err)
+	}
+}
+
+func TestClientDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, request := range readRequests(t, conn, 1) {
+			respond(t, conn, request)
+		}
+	}()
+
+	client, err := Dial(context.Background(), ln.Addr().String(), ClientConfig{})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	response, err := client.Send(context.Background(), newTestRequest("000001"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.Fields[39] != "00" {
+		t.Errorf("Expected response code 00, got %s", response.Fields[39])
+	}
+}
@@ -0,0 +1,163 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"iso8583"
+)
+
+// ErrLimited is returned when a request is rejected by an in-flight or
+// rate limit, so that callers can decline it locally.
+var ErrLimited = errors.New("limit exceeded")
+
+// Limits restricts the requests in flight and their rate.
+type Limits struct {
+	// MaxInFlight is the maximum number of requests waiting for a
+	// response, unlimited if zero.
+	MaxInFlight int
+	// Rate is the maximum number of requests per second, unlimited if
+	// zero.
+	Rate float64
+	// Burst is the number of requests that can be sent at once above the
+	// rate, at least 1.
+	Burst int
+	// Block waits for the limits to allow a request until its context is
+	// done instead of rejecting it at once. Servers wait once the request
+	// has been read, without reading the next requests of the connection
+	// meanwhile.
+	Block bool
+}
+
+// limiter enforces Limits. A nil limiter allows every request.
+type limiter struct {
+	inFlight chan struct{}
+	bucket   *tokenBucket
+	block    bool
+}
+
+// newLimiter returns a limiter for the limits, or nil without limits.
+func newLimiter(l Limits) *limiter {
+	if l.MaxInFlight <= 0 && l.Rate <= 0 {
+		return nil
+	}
+
+	lim := &limiter{block: l.Block}
+	if l.MaxInFlight > 0 {
+		lim.inFlight = make(chan struct{}, l.MaxInFlight)
+	}
+	if l.Rate > 0 {
+		lim.bucket = newTokenBucket(l.Rate, l.Burst)
+	}
+	return lim
+}
+
+// acquire reserves room for a request, returning the function releasing
+// it once answered.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		default:
+			if !l.block {
+				return nil, fmt.Errorf("%w: %d requests in flight", ErrLimited, cap(l.inFlight))
+			}
+			select {
+			case l.inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%w: %w", ErrLimited, ctx.Err())
+			}
+		}
+	}
+
+	release := func() {
+		if l.inFlight != nil {
+			<-l.inFlight
+		}
+	}
+
+	if l.bucket != nil {
+		if err := l.bucket.take(ctx, l.block); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// declineLimited declines a request rejected by the limits with response
+// code 91.
+func declineLimited(req *Request) (*iso8583.MessageBuilder, error) {
+	return decline(req, ErrLimited)
+}
+
+// tokenBucket is a token bucket rate limiter.
+type tokenBucket struct {
+	rate  float64 // Tokens per second
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// take removes a token, waiting for one if block is set.
+func (b *tokenBucket) take(ctx context.Context, block bool) error {
+	for {
+		wait := b.reserve()
+		if wait == 0 {
+			return nil
+		}
+		if !block {
+			return fmt.Errorf("%w: more than %g requests per second", ErrLimited, b.rate)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ErrLimited, ctx.Err())
+		}
+	}
+}
+
+// reserve removes a token if one is available, otherwise it returns the
+// time until the next one.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
@@ -0,0 +1,198 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"iso8583"
+)
+
+func TestLimiterInFlight(t *testing.T) {
+	lim := newLimiter(Limits{MaxInFlight: 2})
+
+	release1, err := lim.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	if _, err := lim.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	if _, err := lim.acquire(context.Background()); !errors.Is(err, ErrLimited) {
+		t.Errorf("Expected ErrLimited over the in-flight limit, got %v", err)
+	}
+
+	release1()
+	if _, err := lim.acquire(context.Background()); err != nil {
+		t.Errorf("Expected room after release, got %v", err)
+	}
+
+	lim.block = true
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := lim.acquire(ctx); !errors.Is(err, ErrLimited) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected ErrLimited and DeadlineExceeded when blocking, got %v", err)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	lim := newLimiter(Limits{Rate: 50, Burst: 2})
+
+	for i := 0; i < 2; i++ {
+		if _, err := lim.acquire(context.Background()); err != nil {
+			t.Fatalf("acquire() within burst error = %v", err)
+		}
+	}
+	if _, err := lim.acquire(context.Background()); !errors.Is(err, ErrLimited) {
+		t.Errorf("Expected ErrLimited over the rate, got %v", err)
+	}
+
+	lim.block = true
+	start := time.Now()
+	if _, err := lim.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire() blocking error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected to wait for a token, waited %s", elapsed)
+	}
+
+	if newLimiter(Limits{}) != nil {
+		t.Errorf("Expected no limiter without limits")
+	}
+}
+
+func TestClientLimits(t *testing.T) {
+	host := NewMockHost(MockConfig{})
+	defer host.Close()
+	host.On("0200").Delay(100 * time.Millisecond).Respond(map[int]string{39: "00"})
+	host.On("0800").RespondFunc(func(req *iso8583.Parser) (*iso8583.MessageBuilder, error) {
+		response, err := iso8583.NewResponse(req)
+		if err != nil {
+			return nil, err
+		}
+		return response.AddField(39, "00"), nil
+	})
+
+	client := NewClient(host.Pipe(), ClientConfig{Limits: Limits{MaxInFlight: 1}})
+	defer client.Close()
+
+	sent := make(chan error, 1)
+	go func() {
+		_, err := client.Send(context.Background(), newTestRequest("000001"))
+		sent <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for len(host.Received()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := client.Send(context.Background(), newTestRequest("000002")); !errors.Is(err, ErrLimited) {
+		t.Errorf("Expected ErrLimited, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Echo(ctx); err != nil {
+		t.Errorf("Expected network management to bypass the limits, got %v", err)
+	}
+
+	if err := <-sent; err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	release := make(chan struct{})
+
+	mux := NewMux()
+	mux.HandleFunc("0200", func(req *Request) (*iso8583.MessageBuilder, error) {
+		<-release
+		return approve(req)
+	})
+
+	errs := make(chan error, 1)
+	server := NewServer(ServerConfig{
+		Handler: mux,
+		Limits:  Limits{MaxInFlight: 1},
+		Error:   func(err error) { errs <- err },
+	})
+	defer server.Close()
+
+	clientConn, serverConn := net.Pipe()
+	go server.ServeConn(serverConn)
+
+	client := NewClient(clientConn, ClientConfig{})
+	defer client.Close()
+
+	type result struct {
+		response *iso8583.Parser
+		err      error
+	}
+	results := make(chan result, 2)
+	for i := 1; i <= 2; i++ {
+		go func(stan string) {
+			response, err := client.Send(context.Background(), newTestRequest(stan))
+			results <- result{response, err}
+		}(fmt.Sprintf("%06d", i))
+	}
+
+	// The request over the limit is declined while the first is in flight
+	r := <-results
+	if r.err != nil {
+		t.Fatalf("Send() error = %v", r.err)
+	}
+	if r.response.Fields[39] != "91" {
+		t.Errorf("Expected response code 91, got %s", r.response.Fields[39])
+	}
+	if err := <-errs; !errors.Is(err, ErrLimited) {
+		t.Errorf("Expected ErrLimited, got %v", err)
+	}
+
+	close(release)
+	r = <-results
+	if r.err != nil || r.response.Fields[39] != "00" {
+		t.Errorf("Expected approved response, got %v", r.err)
+	}
+}
+
+func TestServerLimitsEcho(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	handling := make(chan struct{}, 1)
+	mux := NewMux()
+	mux.HandleFunc("0200", func(req *Request) (*iso8583.MessageBuilder, error) {
+		handling <- struct{}{}
+		<-release
+		return approve(req)
+	})
+
+	server := NewServer(ServerConfig{
+		Handler:           mux,
+		NetworkManagement: true,
+		Limits:            Limits{MaxInFlight: 1, Rate: 1, Burst: 1},
+	})
+	defer server.Close()
+
+	clientConn, serverConn := net.Pipe()
+	go server.ServeConn(serverConn)
+
+	client := NewClient(clientConn, ClientConfig{})
+	defer client.Close()
+
+	go client.Send(context.Background(), newTestRequest("000001"))
+	<-handling
+
+	// The in-flight and rate limits are both used up by the request
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if err := client.Echo(ctx); err != nil {
+			t.Errorf("Echo() error = %v", err)
+		}
+	}
+}
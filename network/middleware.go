@@ -0,0 +1,116 @@
+package network
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"iso8583"
+)
+
+// Middleware wraps a handler with logic run around it, with access to
+// the request, its raw frame and the response.
+type Middleware func(next Handler) Handler
+
+// Chain wraps the handler with the middlewares, the first outermost.
+func Chain(h Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// Recovery turns panics in handlers into errors, so that a failing
+// request does not bring down the server.
+func Recovery() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (response *iso8583.MessageBuilder, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					response = nil
+					err = fmt.Errorf("panic handling %s: %v\n%s", req.Message.MTI, r, debug.Stack())
+				}
+			}()
+			return next.ServeISO(req)
+		})
+	}
+}
+
+// AccessLog logs each request with its STAN, the response MTI and code,
+// and the time taken, to logger or the standard logger if nil.
+func AccessLog(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (*iso8583.MessageBuilder, error) {
+			start := time.Now()
+			response, err := next.ServeISO(req)
+
+			result := "no response"
+			switch {
+			case err != nil:
+				result = "error: " + err.Error()
+			case response != nil:
+				result = fmt.Sprintf("%s %s", response.MTI, response.Fields[39])
+			}
+
+			logger.Printf("%s %s stan=%s %d bytes -> %s (%s)",
+				req.RemoteAddr, req.Message.MTI, req.Message.Fields[11], len(req.Raw), result, time.Since(start))
+
+			return response, err
+		})
+	}
+}
+
+// Validation declines requests with response code 30 (format error)
+// when a numeric or signed amount field does not match its spec
+// content type, or a required field is missing.
+func Validation(required ...int) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (*iso8583.MessageBuilder, error) {
+			if err := validateRequest(req.Message, required); err != nil {
+				response, rerr := iso8583.NewResponse(req.Message)
+				if rerr != nil {
+					return nil, err
+				}
+				return response.AddField(39, "30"), nil
+			}
+			return next.ServeISO(req)
+		})
+	}
+}
+
+// validateRequest checks the fields of a request against its spec.
+func validateRequest(msg *iso8583.Parser, required []int) error {
+	for _, fieldNum := range required {
+		if _, ok := msg.Fields[fieldNum]; !ok {
+			return fmt.Errorf("missing field %d", fieldNum)
+		}
+	}
+
+	spec := msg.Spec()
+	for fieldNum, value := range msg.Fields {
+		elem, ok := spec.Element(fieldNum)
+		if !ok {
+			return fmt.Errorf("unsupported field %d", fieldNum)
+		}
+
+		switch elem.ContentType {
+		case "n":
+			for _, r := range value {
+				if r < '0' || r > '9' {
+					return fmt.Errorf("field %d: invalid numeric value %q", fieldNum, value)
+				}
+			}
+		case "x+n":
+			if _, err := iso8583.ParseSignedAmount(value); err != nil {
+				return fmt.Errorf("field %d: %v", fieldNum, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"iso8583"
+)
+
+// parseTestRequest returns a parsed 0200 request with the given STAN.
+func parseTestRequest(t *testing.T, stan string) *Request {
+	raw, err := newTestRequest(stan).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	msg, err := iso8583.NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return &Request{Message: msg, Raw: []byte(raw)}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(req *Request) (*iso8583.MessageBuilder, error) {
+				calls = append(calls, name)
+				return next.ServeISO(req)
+			})
+		}
+	}
+
+	h := Chain(HandlerFunc(approve), trace("first"), trace("second"))
+	if _, err := h.ServeISO(parseTestRequest(t, "000001")); err != nil {
+		t.Fatalf("ServeISO() error = %v", err)
+	}
+
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("Expected first,second, got %v", calls)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	h := Chain(HandlerFunc(func(req *Request) (*iso8583.MessageBuilder, error) {
+		panic("boom")
+	}), Recovery())
+
+	response, err := h.ServeISO(parseTestRequest(t, "000001"))
+	if response != nil {
+		t.Errorf("Expected no response after panic")
+	}
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected panic as error, got %v", err)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	h := Chain(HandlerFunc(approve), AccessLog(log.New(&buf, "", 0)))
+
+	if _, err := h.ServeISO(parseTestRequest(t, "000042")); err != nil {
+		t.Fatalf("ServeISO() error = %v", err)
+	}
+
+	line := buf.String()
+	for _, expected := range []string{"0200", "stan=000042", "0210 00"} {
+		if !strings.Contains(line, expected) {
+			t.Errorf("Expected access log to contain %q, got %q", expected, line)
+		}
+	}
+}
+
+func TestValidation(t *testing.T) {
+	h := Chain(HandlerFunc(approve), Validation(4, 11, 41))
+
+	response, err := h.ServeISO(parseTestRequest(t, "000001"))
+	if err != nil {
+		t.Fatalf("ServeISO() error = %v", err)
+	}
+	if response.Fields[39] != "00" {
+		t.Errorf("Expected valid request to be approved, got %s", response.Fields[39])
+	}
+
+	req := parseTestRequest(t, "000002")
+	delete(req.Message.Fields, 41)
+	response, _ = h.ServeISO(req)
+	if response.Fields[39] != "30" {
+		t.Errorf("Expected response code 30 for missing field, got %s", response.Fields[39])
+	}
+
+	req = parseTestRequest(t, "000003")
+	req.Message.Fields[4] = "00000000100A"
+	response, _ = h.ServeISO(req)
+	if response.Fields[39] != "30" {
+		t.Errorf("Expected response code 30 for invalid numeric field, got %s", response.Fields[39])
+	}
+}
+
+func TestServerMiddleware(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("0200", func(req *Request) (*iso8583.MessageBuilder, error) {
+		panic("boom")
+	})
+
+	var buf bytes.Buffer
+	errs := make(chan error, 1)
+	server := NewServer(ServerConfig{
+		Handler:    mux,
+		Middleware: []Middleware{AccessLog(log.New(&buf, "", 0)), Recovery()},
+		Error:      func(err error) { errs <- err },
+	})
+	defer server.Close()
+
+	clientConn, serverConn := net.Pipe()
+	go server.ServeConn(serverConn)
+
+	client := NewClient(clientConn, ClientConfig{})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client.Send(ctx, newTestRequest("000001"))
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "panic") {
+			t.Errorf("Expected recovered panic, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected recovered panic to be reported")
+	}
+
+	if !strings.Contains(buf.String(), "error: panic") {
+		t.Errorf("Expected access log of the failed request, got %q", buf.String())
+	}
+}
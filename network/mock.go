@@ -0,0 +1,342 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"iso8583"
+	"iso8583/framing"
+)
+
+// MockRule scripts how a MockHost answers the requests it matches. Its
+// methods can be called while the host is serving.
+type MockRule struct {
+	host      *MockHost
+	mti       string
+	fields    map[int]string
+	response  map[int]string
+	respond   func(req *iso8583.Parser) (*iso8583.MessageBuilder, error)
+	delay     time.Duration
+	drop      bool
+	malformed []byte
+}
+
+// WithField restricts the rule to requests with the field value, as
+// received, e.g. with fixed fields padded.
+func (r *MockRule) WithField(fieldNum int, value string) *MockRule {
+	r.host.mu.Lock()
+	defer r.host.mu.Unlock()
+	r.fields[fieldNum] = value
+	return r
+}
+
+// Respond answers with the response derived by NewResponse with the
+// given fields added, e.g. the response code (39).
+func (r *MockRule) Respond(fields map[int]string) *MockRule {
+	response := make(map[int]string, len(fields))
+	for n, v := range fields {
+		response[n] = v
+	}
+
+	r.host.mu.Lock()
+	defer r.host.mu.Unlock()
+	r.response = response
+	return r
+}
+
+// RespondFunc answers with the response built by f.
+func (r *MockRule) RespondFunc(f func(req *iso8583.Parser) (*iso8583.MessageBuilder, error)) *MockRule {
+	r.host.mu.Lock()
+	defer r.host.mu.Unlock()
+	r.respond = f
+	return r
+}
+
+// Delay waits before answering.
+func (r *MockRule) Delay(d time.Duration) *MockRule {
+	r.host.mu.Lock()
+	defer r.host.mu.Unlock()
+	r.delay = d
+	return r
+}
+
+// Drop sends no response.
+func (r *MockRule) Drop() *MockRule {
+	r.host.mu.Lock()
+	defer r.host.mu.Unlock()
+	r.drop = true
+	return r
+}
+
+// SendMalformed answers with data written as is, without framing.
+func (r *MockRule) SendMalformed(data []byte) *MockRule {
+	r.host.mu.Lock()
+	defer r.host.mu.Unlock()
+	r.malformed = append([]byte(nil), data...)
+	return r
+}
+
+// matches reports whether the rule applies to the request.
+func (r *MockRule) matches(msg *iso8583.Parser) bool {
+	if r.mti != "" && r.mti != msg.MTI {
+		return false
+	}
+	for fieldNum, value := range r.fields {
+		if msg.Fields[fieldNum] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// MockConfig configures a MockHost.
+type MockConfig struct {
+	// Spec used to parse and build messages, DefaultSpec if nil.
+	Spec *iso8583.Spec
+	// Framer used to read and write messages, a 2 byte binary length
+	// header if nil.
+	Framer *framing.Framer
+}
+
+// MockHost is a scripted host for tests. It answers requests according
+// to the first rule matching them, sends nothing for requests without a
+// matching rule, and records every message it receives as well as the
+// messages it fails to parse or answer.
+type MockHost struct {
+	config MockConfig
+
+	mu        sync.Mutex
+	rules     []*MockRule
+	received  []*iso8583.Parser
+	errs      []error
+	listeners []net.Listener
+	conns     map[net.Conn]struct{}
+	done      chan struct{} // Closed on Close
+	wg        sync.WaitGroup
+}
+
+// NewMockHost returns a mock host without rules.
+func NewMockHost(config MockConfig) *MockHost {
+	if config.Spec == nil {
+		config.Spec = iso8583.DefaultSpec
+	}
+	if config.Framer == nil {
+		config.Framer = framing.New(framing.Binary2)
+	}
+	return &MockHost{
+		config: config,
+		conns:  make(map[net.Conn]struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// On adds a rule for requests with the MTI, or any MTI if empty.
+func (h *MockHost) On(mti string) *MockRule {
+	rule := &MockRule{host: h, mti: mti, fields: make(map[int]string)}
+
+	h.mu.Lock()
+	h.rules = append(h.rules, rule)
+	h.mu.Unlock()
+
+	return rule
+}
+
+// Listen serves connections on a local port and returns its address.
+func (h *MockHost) Listen() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	h.mu.Lock()
+	h.listeners = append(h.listeners, ln)
+	h.mu.Unlock()
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			h.serve(conn)
+		}
+	}()
+
+	return ln.Addr().String(), nil
+}
+
+// Pipe returns the client side of an in-memory connection to the host.
+func (h *MockHost) Pipe() net.Conn {
+	clientConn, hostConn := net.Pipe()
+	h.serve(hostConn)
+	return clientConn
+}
+
+// Received returns the messages received so far, in order.
+func (h *MockHost) Received() []*iso8583.Parser {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*iso8583.Parser(nil), h.received...)
+}
+
+// Errors returns the errors parsing received messages and building
+// responses so far, in order.
+func (h *MockHost) Errors() []error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]error(nil), h.errs...)
+}
+
+// fail records an error.
+func (h *MockHost) fail(err error) {
+	h.mu.Lock()
+	h.errs = append(h.errs, err)
+	h.mu.Unlock()
+}
+
+// Close closes the listeners and connections.
+func (h *MockHost) Close() error {
+	h.mu.Lock()
+	select {
+	case <-h.done:
+	default:
+		close(h.done)
+	}
+	for _, ln := range h.listeners {
+		ln.Close()
+	}
+	for conn := range h.conns {
+		conn.Close()
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+	return nil
+}
+
+// serve starts reading requests from conn, or closes it if the host is
+// closed.
+func (h *MockHost) serve(conn net.Conn) {
+	h.mu.Lock()
+	select {
+	case <-h.done:
+		h.mu.Unlock()
+		conn.Close()
+		return
+	default:
+	}
+	h.conns[conn] = struct{}{}
+	h.wg.Add(1)
+	h.mu.Unlock()
+
+	var writeMu sync.Mutex
+	write := func(data []byte, framed bool) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if framed {
+			h.config.Framer.WriteFrame(conn, framing.Frame{Message: data})
+		} else {
+			conn.Write(data)
+		}
+	}
+
+	go func() {
+		defer h.wg.Done()
+		defer func() {
+			h.mu.Lock()
+			delete(h.conns, conn)
+			h.mu.Unlock()
+			conn.Close()
+		}()
+
+		for {
+			raw, err := h.config.Framer.ReadMessage(conn)
+			if err != nil {
+				return
+			}
+
+			msg, err := iso8583.NewParserWithSpec(h.config.Spec).Parse(raw)
+			if err != nil {
+				h.fail(fmt.Errorf("error parsing message: %w", err))
+				continue
+			}
+
+			h.mu.Lock()
+			h.received = append(h.received, msg)
+			h.mu.Unlock()
+
+			if rule := h.match(msg); rule != nil {
+				h.wg.Add(1)
+				go func() {
+					defer h.wg.Done()
+					h.answer(rule, msg, write)
+				}()
+			}
+		}
+	}()
+}
+
+// match returns a copy of the first rule matching the request, so that
+// the answer is not affected by later changes to the rule.
+func (h *MockHost) match(msg *iso8583.Parser) *MockRule {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, rule := range h.rules {
+		if rule.matches(msg) {
+			matched := *rule
+			return &matched
+		}
+	}
+	return nil
+}
+
+// answer applies the rule to the request.
+func (h *MockHost) answer(rule *MockRule, msg *iso8583.Parser, write func(data []byte, framed bool)) {
+	select {
+	case <-time.After(rule.delay):
+	case <-h.done:
+		return
+	}
+
+	switch {
+	case rule.drop:
+		return
+	case rule.malformed != nil:
+		write(rule.malformed, false)
+		return
+	}
+
+	response, err := h.response(rule, msg)
+	if err != nil {
+		h.fail(err)
+		return
+	}
+
+	raw, err := response.Build()
+	if err != nil {
+		h.fail(fmt.Errorf("error building response to %s: %w", msg.MTI, err))
+		return
+	}
+	write([]byte(raw), true)
+}
+
+// response builds the response to the request from the rule.
+func (h *MockHost) response(rule *MockRule, msg *iso8583.Parser) (*iso8583.MessageBuilder, error) {
+	if rule.respond != nil {
+		return rule.respond(msg)
+	}
+
+	response, err := iso8583.NewResponse(msg)
+	if err != nil {
+		return nil, fmt.Errorf("error responding to %s: %w", msg.MTI, err)
+	}
+	for fieldNum, value := range rule.response {
+		response.AddField(fieldNum, value)
+	}
+	return response, nil
+}
@@ -0,0 +1,202 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"iso8583"
+	"iso8583/framing"
+)
+
+func TestMockHostRules(t *testing.T) {
+	host := NewMockHost(MockConfig{})
+	defer host.Close()
+
+	host.On("0200").WithField(4, "000000009999").Respond(map[int]string{39: "51"})
+	host.On("0200").Respond(map[int]string{39: "00", 38: "ABC123"})
+
+	client := NewClient(host.Pipe(), ClientConfig{})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	response, err := client.Send(ctx, newTestRequest("000001"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.MTI != "0210" || response.Fields[39] != "00" || response.Fields[38] != "ABC123" {
+		t.Errorf("Expected approved 0210 with code ABC123, got %s with %s and %s", response.MTI, response.Fields[39], response.Fields[38])
+	}
+
+	declined := newTestRequest("000002").AddField(4, "9999")
+	response, err = client.Send(ctx, declined)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.Fields[39] != "51" {
+		t.Errorf("Expected response code 51 from the first matching rule, got %s", response.Fields[39])
+	}
+
+	received := host.Received()
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 received messages, got %d", len(received))
+	}
+	if received[0].Fields[11] != "000001" || received[1].Fields[11] != "000002" {
+		t.Errorf("Expected STANs 000001 and 000002, got %s and %s", received[0].Fields[11], received[1].Fields[11])
+	}
+}
+
+func TestMockHostDelayAndDrop(t *testing.T) {
+	host := NewMockHost(MockConfig{})
+	defer host.Close()
+
+	host.On("0200").WithField(11, "000001").Delay(200 * time.Millisecond).Respond(map[int]string{39: "00"})
+	host.On("0200").WithField(11, "000002").Drop()
+
+	addr, err := host.Listen()
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	client, err := Dial(context.Background(), addr, ClientConfig{})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	for _, stan := range []string{"000001", "000002", "000003"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := client.Send(ctx, newTestRequest(stan))
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("STAN %s: expected DeadlineExceeded, got %v", stan, err)
+		}
+	}
+
+	if received := host.Received(); len(received) != 3 {
+		t.Errorf("Expected 3 received messages, got %d", len(received))
+	}
+}
+
+func TestMockHostMalformed(t *testing.T) {
+	host := NewMockHost(MockConfig{})
+	defer host.Close()
+
+	host.On("0200").SendMalformed([]byte{0x00, 0x04, '0', '2', '1', '0'})
+
+	errs := make(chan error, 1)
+	client := NewClient(host.Pipe(), ClientConfig{Error: func(err error) { errs <- err }})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client.Send(ctx, newTestRequest("000001"))
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Errorf("Expected parse error")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected malformed response to be reported")
+	}
+}
+
+func TestMockHostRespondFunc(t *testing.T) {
+	host := NewMockHost(MockConfig{})
+	defer host.Close()
+
+	host.On("").RespondFunc(func(req *iso8583.Parser) (*iso8583.MessageBuilder, error) {
+		response, err := iso8583.NewResponse(req)
+		if err != nil {
+			return nil, err
+		}
+		return response.AddField(39, "00").AddField(70, req.Fields[70]), nil
+	})
+
+	client := NewClient(host.Pipe(), ClientConfig{})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.SignOn(ctx); err != nil {
+		t.Fatalf("SignOn() error = %v", err)
+	}
+	if received := host.Received(); len(received) != 1 || received[0].Fields[70] != NetworkSignOn {
+		t.Errorf("Expected a recorded sign-on request")
+	}
+}
+
+func TestMockHostErrors(t *testing.T) {
+	host := NewMockHost(MockConfig{})
+	defer host.Close()
+
+	host.On("0200").RespondFunc(func(req *iso8583.Parser) (*iso8583.MessageBuilder, error) {
+		return nil, errors.New("no response")
+	})
+
+	conn := host.Pipe()
+	defer conn.Close()
+
+	framer := framing.New(framing.Binary2)
+	if err := framer.WriteFrame(conn, framing.Frame{Message: []byte("02XX")}); err != nil {
+		t.Fatalf("WriteFrame() error = %v", err)
+	}
+	raw, _ := newTestRequest("000001").Build()
+	if err := framer.WriteFrame(conn, framing.Frame{Message: []byte(raw)}); err != nil {
+		t.Fatalf("WriteFrame() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(host.Errors()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if errs := host.Errors(); len(errs) != 2 {
+		t.Errorf("Expected a parse error and a response error, got %v", errs)
+	}
+	if received := host.Received(); len(received) != 1 {
+		t.Errorf("Expected only the valid message to be received, got %d", len(received))
+	}
+}
+
+func TestMockHostRuleChangedWhileServing(t *testing.T) {
+	host := NewMockHost(MockConfig{})
+	defer host.Close()
+
+	rule := host.On("0200").Respond(map[int]string{39: "00"})
+
+	client := NewClient(host.Pipe(), ClientConfig{})
+	defer client.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			rule.WithField(41, "TERM0001").Delay(0).Respond(map[int]string{39: "00"})
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client.Send(ctx, newTestRequest("000001"))
+	<-done
+}
+
+func TestMockHostClosed(t *testing.T) {
+	host := NewMockHost(MockConfig{})
+	host.Close()
+
+	conn := host.Pipe()
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected the connection to a closed host to be closed, got %v", err)
+	}
+}
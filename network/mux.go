@@ -0,0 +1,68 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+
+	"iso8583"
+)
+
+// Mux dispatches requests to handlers by MTI and, optionally, by the
+// transaction type of the processing code (3).
+type Mux struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	// NotFound handles requests without a matching handler. Without it,
+	// such requests are rejected with an error.
+	NotFound Handler
+}
+
+// NewMux returns an empty Mux.
+func NewMux() *Mux {
+	return &Mux{handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler for requests with the MTI.
+func (m *Mux) Handle(mti string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[mti] = h
+}
+
+// HandleFunc registers the handler function for requests with the MTI.
+func (m *Mux) HandleFunc(mti string, f func(req *Request) (*iso8583.MessageBuilder, error)) {
+	m.Handle(mti, HandlerFunc(f))
+}
+
+// HandleTransaction registers the handler for requests with the MTI and
+// the transaction type. It takes precedence over a handler for the MTI.
+func (m *Mux) HandleTransaction(mti string, transaction iso8583.TransactionType, h Handler) {
+	m.Handle(mti+"/"+string(transaction), h)
+}
+
+// Handler returns the handler for a message.
+func (m *Mux) Handler(msg *iso8583.Parser) (Handler, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if code := msg.Fields[3]; len(code) >= 2 {
+		if h, ok := m.handlers[msg.MTI+"/"+code[:2]]; ok {
+			return h, true
+		}
+	}
+	h, ok := m.handlers[msg.MTI]
+	return h, ok
+}
+
+// ServeISO dispatches the request to its handler.
+func (m *Mux) ServeISO(req *Request) (*iso8583.MessageBuilder, error) {
+	h, ok := m.Handler(req.Message)
+	if !ok {
+		if m.NotFound != nil {
+			return m.NotFound.ServeISO(req)
+		}
+		return nil, fmt.Errorf("no handler for MTI %s", req.Message.MTI)
+	}
+	return h.ServeISO(req)
+}
@@ -0,0 +1,55 @@
+package network
+
+import (
+	"testing"
+
+	"iso8583"
+)
+
+func TestMuxRouting(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("0200", func(req *Request) (*iso8583.MessageBuilder, error) {
+		return iso8583.NewISO().SetMTI("0210"), nil
+	})
+	mux.HandleTransaction("0200", iso8583.TransactionRefund, HandlerFunc(func(req *Request) (*iso8583.MessageBuilder, error) {
+		return iso8583.NewISO().SetMTI("0230"), nil
+	}))
+
+	tests := []struct {
+		mti, code, expected string
+	}{
+		{"0200", "000000", "0210"},
+		{"0200", "200000", "0230"},
+		{"0200", "", "0210"},
+	}
+
+	for _, tt := range tests {
+		msg := iso8583.NewParser()
+		msg.MTI = tt.mti
+		if tt.code != "" {
+			msg.Fields[3] = tt.code
+		}
+
+		response, err := mux.ServeISO(&Request{Message: msg})
+		if err != nil {
+			t.Errorf("ServeISO(%s, %s) error = %v", tt.mti, tt.code, err)
+			continue
+		}
+		if response.MTI != tt.expected {
+			t.Errorf("ServeISO(%s, %s): expected %s, got %s", tt.mti, tt.code, tt.expected, response.MTI)
+		}
+	}
+
+	msg := iso8583.NewParser()
+	msg.MTI = "0100"
+	if _, err := mux.ServeISO(&Request{Message: msg}); err == nil {
+		t.Errorf("Expected error for MTI without handler")
+	}
+
+	mux.NotFound = HandlerFunc(func(req *Request) (*iso8583.MessageBuilder, error) {
+		return nil, nil
+	})
+	if _, err := mux.ServeISO(&Request{Message: msg}); err != nil {
+		t.Errorf("Expected NotFound handler, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"iso8583"
+)
+
+// Network management information codes (70).
+const (
+	NetworkSignOn   = "001"
+	NetworkSignOff  = "002"
+	NetworkEchoTest = "301"
+)
+
+// DefaultNetworkTimeout is the time to wait for network management
+// responses without an explicit NetworkTimeout.
+const DefaultNetworkTimeout = 30 * time.Second
+
+// signOnRetryDelay is the delay between failed automatic sign-ons.
+const signOnRetryDelay = 5 * time.Second
+
+// ErrNotSignedOn is returned when sending a request other than network
+// management before signing on.
+var ErrNotSignedOn = errors.New("not signed on")
+
+// SignOn sends a sign-on request and marks the client signed on when it
+// is approved.
+func (c *Client) SignOn(ctx context.Context) error {
+	if err := c.networkRequest(ctx, NetworkSignOn); err != nil {
+		return err
+	}
+	c.setSignedOn(true)
+	return nil
+}
+
+// SignOff marks the client signed off and sends a sign-off request.
+func (c *Client) SignOff(ctx context.Context) error {
+	c.setSignedOn(false)
+	return c.networkRequest(ctx, NetworkSignOff)
+}
+
+// Echo sends an echo test request.
+func (c *Client) Echo(ctx context.Context) error {
+	return c.networkRequest(ctx, NetworkEchoTest)
+}
+
+// SignedOn reports whether the client is signed on.
+func (c *Client) SignedOn() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.signedOn
+}
+
+// WaitSignedOn waits until the client is signed on, ctx is done or the
+// client stops.
+func (c *Client) WaitSignedOn(ctx context.Context) error {
+	c.mu.Lock()
+	signedOn := c.signedOnCh
+	c.mu.Unlock()
+
+	select {
+	case <-signedOn:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.Err()
+	}
+}
+
+func (c *Client) setSignedOn(signedOn bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if signedOn == c.signedOn {
+		return
+	}
+	c.signedOn = signedOn
+	if signedOn {
+		close(c.signedOnCh)
+	} else {
+		c.signedOnCh = make(chan struct{})
+	}
+}
+
+// networkRequest sends an 0800 with the network management code and
+// checks that it is approved.
+func (c *Client) networkRequest(ctx context.Context, code string) error {
+	request := iso8583.NewISOWithSpec(c.config.Spec)
+	request.SetMTI("0800")
+	for fieldNum, value := range c.config.NetworkFields {
+		request.AddField(fieldNum, value)
+	}
+	request.AddField(7, time.Now().UTC().Format("0102150405"))
+	request.AddField(11, c.nextSTAN())
+	request.AddField(70, code)
+
+	response, err := c.send(ctx, request)
+	if err != nil {
+		return fmt.Errorf("network management %s: %w", code, err)
+	}
+	if rc := response.Fields[39]; rc != "00" {
+		return fmt.Errorf("network management %s declined with response code %s", code, rc)
+	}
+
+	return nil
+}
+
+// nextSTAN returns the STAN of the next network management request.
+func (c *Client) nextSTAN() string {
+	return fmt.Sprintf("%06d", c.stan.Add(1)%1000000)
+}
+
+// networkContext returns a context of parent limited by the network
+// timeout.
+func (c *Client) networkContext(parent context.Context) (context.Context, context.CancelFunc) {
+	timeout := c.config.NetworkTimeout
+	if timeout <= 0 {
+		timeout = DefaultNetworkTimeout
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// manage signs on, if configured, then sends echo tests whenever the
+// connection is idle for the echo interval, until the client stops.
+func (c *Client) manage() {
+	for c.config.SignOn {
+		ctx, cancel := c.networkContext(context.Background())
+		err := c.SignOn(ctx)
+		cancel()
+		if err == nil {
+			break
+		}
+		c.error(err)
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(signOnRetryDelay):
+		}
+	}
+
+	interval := c.config.EchoInterval
+	if interval <= 0 {
+		return
+	}
+
+	for {
+		idle := time.Since(time.Unix(0, c.activity.Load()))
+		if idle >= interval {
+			ctx, cancel := c.networkContext(context.Background())
+			if err := c.Echo(ctx); err != nil {
+				c.error(err)
+			}
+			cancel()
+			idle = 0
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(interval - idle):
+		}
+	}
+}
+
+// answerNetworkRequest answers an 0800 from the host, tracking sign-on
+// and sign-off.
+func (c *Client) answerNetworkRequest(msg *iso8583.Parser) {
+	response, err := networkResponse(msg)
+	if err != nil {
+		c.error(err)
+		return
+	}
+
+	switch msg.Fields[70] {
+	case NetworkSignOn:
+		c.setSignedOn(true)
+	case NetworkSignOff:
+		c.setSignedOn(false)
+	}
+
+	raw, err := response.Build()
+	if err != nil {
+		c.error(fmt.Errorf("error building network management response: %w", err))
+		return
+	}
+
+	ctx, cancel := c.networkContext(context.Background())
+	defer cancel()
+	if err := c.write(ctx, raw); err != nil {
+		c.error(fmt.Errorf("error writing network management response: %w", err))
+	}
+}
+
+// networkResponse returns the approved response to a network management
+// request.
+func networkResponse(msg *iso8583.Parser) (*iso8583.MessageBuilder, error) {
+	response, err := iso8583.NewResponse(msg)
+	if err != nil {
+		return nil, err
+	}
+	response.AddField(39, "00")
+	response.AddField(70, msg.Fields[70])
+	return response, nil
+}
+
+// isNetworkManagement reports whether the MTI is of the network
+// management class.
+func isNetworkManagement(mti string) bool {
+	return len(mti) == 4 && mti[1] == '8'
+}
+
+// serveNetworkRequest answers an 0800 on a server connection, tracking
+// sign-on and sign-off.
+func (c *serverConn) serveNetworkRequest(req *Request) (*iso8583.MessageBuilder, error) {
+	switch req.Message.Fields[70] {
+	case NetworkSignOn:
+		c.signedOn.Store(true)
+	case NetworkSignOff:
+		c.signedOn.Store(false)
+	}
+	return networkResponse(req.Message)
+}
+
+// declineNotSignedOn declines a request received before sign-on with
+// response code 91.
+func declineNotSignedOn(req *Request) (*iso8583.MessageBuilder, error) {
+	return decline(req, ErrNotSignedOn)
+}
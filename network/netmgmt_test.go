@@ -0,0 +1,167 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"iso8583"
+	"iso8583/framing"
+)
+
+// newNetworkServer serves a connection with network management and a
+// handler approving 0200 requests.
+func newNetworkServer(t *testing.T, conn net.Conn, requireSignOn bool) *Server {
+	mux := NewMux()
+	mux.HandleFunc("0200", approve)
+
+	server := NewServer(ServerConfig{
+		Handler:           mux,
+		NetworkManagement: true,
+		RequireSignOn:     requireSignOn,
+	})
+	go server.ServeConn(conn)
+
+	return server
+}
+
+func TestClientSignOn(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	server := newNetworkServer(t, serverConn, true)
+	defer server.Close()
+
+	client := NewClient(clientConn, ClientConfig{SignOn: true})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.WaitSignedOn(ctx); err != nil {
+		t.Fatalf("WaitSignedOn() error = %v", err)
+	}
+	if !client.SignedOn() {
+		t.Errorf("Expected client to be signed on")
+	}
+
+	response, err := client.Send(ctx, newTestRequest("000001"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.Fields[39] != "00" {
+		t.Errorf("Expected response code 00, got %s", response.Fields[39])
+	}
+
+	if err := client.SignOff(ctx); err != nil {
+		t.Fatalf("SignOff() error = %v", err)
+	}
+	if _, err := client.Send(ctx, newTestRequest("000002")); !errors.Is(err, ErrNotSignedOn) {
+		t.Errorf("Expected ErrNotSignedOn after sign-off, got %v", err)
+	}
+
+	if err := client.Echo(ctx); err != nil {
+		t.Errorf("Expected echo test while signed off, got %v", err)
+	}
+}
+
+func TestServerRequireSignOn(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	server := newNetworkServer(t, serverConn, true)
+	defer server.Close()
+
+	client := NewClient(clientConn, ClientConfig{})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	response, err := client.Send(ctx, newTestRequest("000001"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.Fields[39] != "91" {
+		t.Errorf("Expected response code 91 before sign-on, got %s", response.Fields[39])
+	}
+
+	if err := client.SignOn(ctx); err != nil {
+		t.Fatalf("SignOn() error = %v", err)
+	}
+
+	response, err = client.Send(ctx, newTestRequest("000002"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.Fields[39] != "00" {
+		t.Errorf("Expected response code 00 after sign-on, got %s", response.Fields[39])
+	}
+}
+
+func TestClientEchoOnIdle(t *testing.T) {
+	clientConn, hostConn := net.Pipe()
+	defer hostConn.Close()
+
+	client := NewClient(clientConn, ClientConfig{EchoInterval: 20 * time.Millisecond})
+	defer client.Close()
+
+	var requests []*iso8583.Parser
+	for i := 0; i < 2; i++ {
+		for _, request := range readRequests(t, hostConn, 1) {
+			respond(t, hostConn, request)
+			requests = append(requests, request)
+		}
+	}
+	for _, request := range requests {
+		if request.MTI != "0800" || request.Fields[70] != NetworkEchoTest {
+			t.Errorf("Expected 0800 echo test, got %s with code %s", request.MTI, request.Fields[70])
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 echo tests, got %d", len(requests))
+	}
+	if requests[0].Fields[11] == requests[1].Fields[11] {
+		t.Errorf("Expected distinct STANs, got %s twice", requests[0].Fields[11])
+	}
+}
+
+func TestClientAnswersNetworkRequest(t *testing.T) {
+	clientConn, hostConn := net.Pipe()
+	defer hostConn.Close()
+
+	client := NewClient(clientConn, ClientConfig{NetworkManagement: true})
+	defer client.Close()
+
+	request := iso8583.NewISO()
+	request.SetMTI("0800")
+	request.AddField(7, "0101120000")
+	request.AddField(11, "000123")
+	request.AddField(70, NetworkSignOn)
+	raw, _ := request.Build()
+
+	f := framing.New(framing.Binary2)
+	go f.WriteMessage(hostConn, raw)
+
+	raw, err := f.ReadMessage(hostConn)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	response, err := iso8583.NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if response.MTI != "0810" || response.Fields[39] != "00" || response.Fields[70] != NetworkSignOn {
+		t.Errorf("Expected approved 0810 sign-on, got %s with response code %s and code %s", response.MTI, response.Fields[39], response.Fields[70])
+	}
+	if response.Fields[11] != "000123" {
+		t.Errorf("Expected STAN 000123, got %s", response.Fields[11])
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !client.SignedOn() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !client.SignedOn() {
+		t.Errorf("Expected client to be signed on by the host")
+	}
+}
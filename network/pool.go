@@ -0,0 +1,372 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"iso8583"
+)
+
+// ErrNoConnection is returned when a pool has no healthy connection to
+// send a request on.
+var ErrNoConnection = errors.New("no healthy connection")
+
+// Balancer selects the connection each request of a pool is sent on.
+type Balancer int
+
+// List of balancers.
+const (
+	RoundRobin    Balancer = iota // Each connection in turn
+	LeastInFlight                 // The connection with fewest pending requests
+)
+
+// Default pool settings.
+const (
+	DefaultCheckInterval   = 10 * time.Second
+	DefaultMaxMissedEchoes = 3
+)
+
+// PoolConfig configures a Pool.
+type PoolConfig struct {
+	// Endpoints are the addresses of the hosts.
+	Endpoints []string
+	// ConnsPerEndpoint is the number of connections to each endpoint,
+	// at least 1.
+	ConnsPerEndpoint int
+	// Balancer distributes requests over the connections.
+	Balancer Balancer
+	// Client configures each connection.
+	Client ClientConfig
+	// CheckInterval is the interval between health checks, which redial
+	// failed connections and send echo tests, DefaultCheckInterval if
+	// zero.
+	CheckInterval time.Duration
+	// Echo sends an echo test on every connection at each health check.
+	Echo bool
+	// MaxMissedEchoes is the number of consecutive failed echo tests
+	// after which a connection is no longer used until an echo test
+	// succeeds, DefaultMaxMissedEchoes if zero. An endpoint is unhealthy
+	// when none of its connections can be used.
+	MaxMissedEchoes int
+}
+
+// EndpointStatus is the state of an endpoint of a pool.
+type EndpointStatus struct {
+	Addr     string
+	Healthy  bool
+	Conns    int // Connections ready to send requests
+	InFlight int // Requests waiting for a response
+}
+
+// Pool sends requests over several connections to one or more endpoints.
+// Each request is sent on a single connection, where its response is
+// matched, and requests that could not be sent are retried on another
+// connection. It is safe for concurrent use.
+type Pool struct {
+	config    PoolConfig
+	endpoints []*endpoint
+	next      atomic.Uint64
+
+	ctx    context.Context // Canceled on Close
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// endpoint is a host of a pool with its connections.
+type endpoint struct {
+	addr  string
+	conns []*poolConn
+}
+
+// poolConn is a connection slot of a pool.
+type poolConn struct {
+	endpoint *endpoint
+	client   *Client // nil until connected
+	inFlight atomic.Int64
+	missed   int   // Consecutive failed echo tests
+	err      error // Last failure to connect
+}
+
+// NewPool connects to the endpoints and returns a pool sending requests
+// over the connections. It fails if no connection can be made.
+func NewPool(ctx context.Context, config PoolConfig) (*Pool, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints")
+	}
+	if config.ConnsPerEndpoint < 1 {
+		config.ConnsPerEndpoint = 1
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = DefaultCheckInterval
+	}
+	if config.MaxMissedEchoes <= 0 {
+		config.MaxMissedEchoes = DefaultMaxMissedEchoes
+	}
+
+	p := &Pool{config: config}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	for _, addr := range config.Endpoints {
+		e := &endpoint{addr: addr}
+		for i := 0; i < config.ConnsPerEndpoint; i++ {
+			e.conns = append(e.conns, &poolConn{endpoint: e})
+		}
+		p.endpoints = append(p.endpoints, e)
+	}
+
+	p.connect(ctx)
+
+	p.mu.Lock()
+	ready, err := len(p.ready()) > 0, p.connErr()
+	p.mu.Unlock()
+
+	if !ready {
+		p.Close()
+		return nil, fmt.Errorf("error connecting to %v: %w: %w", config.Endpoints, ErrNoConnection, err)
+	}
+
+	p.wg.Add(1)
+	go p.check()
+
+	return p, nil
+}
+
+// Send sends a request on a connection chosen by the balancer and waits
+// for its response until ctx is done. When no connection can take the
+// request, the error wraps ErrNoConnection and the last failure.
+func (p *Pool) Send(ctx context.Context, request *iso8583.MessageBuilder) (*iso8583.Parser, error) {
+	tried := make(map[*poolConn]bool)
+	var lastErr error
+
+	for {
+		pc, client := p.pick(tried)
+		if pc == nil {
+			if lastErr == nil {
+				p.mu.Lock()
+				lastErr = p.connErr()
+				p.mu.Unlock()
+			}
+			if lastErr == nil {
+				return nil, ErrNoConnection
+			}
+			return nil, fmt.Errorf("%w: %w", ErrNoConnection, lastErr)
+		}
+
+		pc.inFlight.Add(1)
+		response, err := client.Send(ctx, request)
+		pc.inFlight.Add(-1)
+
+		if err == nil {
+			return response, nil
+		}
+
+		// Fail over when the request was not sent on this connection
+		var notSent notSentError
+		if !errors.As(err, &notSent) || ctx.Err() != nil || errors.Is(err, ErrDuplicateKey) {
+			return nil, err
+		}
+		tried[pc] = true
+		lastErr = err
+	}
+}
+
+// pick returns a ready connection not yet tried with its client, or nil.
+func (p *Pool) pick(tried map[*poolConn]bool) (*poolConn, *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var candidates []*poolConn
+	for _, pc := range p.ready() {
+		if !tried[pc] {
+			candidates = append(candidates, pc)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var pc *poolConn
+	switch p.config.Balancer {
+	case LeastInFlight:
+		pc = candidates[0]
+		for _, candidate := range candidates[1:] {
+			if candidate.inFlight.Load() < pc.inFlight.Load() {
+				pc = candidate
+			}
+		}
+	default:
+		pc = candidates[p.next.Add(1)%uint64(len(candidates))]
+	}
+	return pc, pc.client
+}
+
+// ready returns the connections that can send requests. p.mu must be
+// held.
+func (p *Pool) ready() []*poolConn {
+	var conns []*poolConn
+	for _, e := range p.endpoints {
+		for _, pc := range e.conns {
+			if pc.usable(p.config.MaxMissedEchoes) {
+				conns = append(conns, pc)
+			}
+		}
+	}
+	return conns
+}
+
+// connErr returns the last failure of a connection, or nil. p.mu must
+// be held.
+func (p *Pool) connErr() error {
+	var err error
+	for _, e := range p.endpoints {
+		for _, pc := range e.conns {
+			if pc.client != nil && pc.client.Err() != nil {
+				err = pc.client.Err()
+			} else if pc.err != nil {
+				err = pc.err
+			}
+		}
+	}
+	return err
+}
+
+// usable reports whether the connection can send requests.
+func (pc *poolConn) usable(maxMissed int) bool {
+	if pc.client == nil || pc.client.Err() != nil || pc.missed >= maxMissed {
+		return false
+	}
+	return !pc.client.config.SignOn || pc.client.SignedOn()
+}
+
+// connect dials the connections that are missing or have failed.
+func (p *Pool) connect(ctx context.Context) {
+	for _, e := range p.endpoints {
+		for _, pc := range e.conns {
+			p.mu.Lock()
+			client := pc.client
+			p.mu.Unlock()
+
+			if client != nil && client.Err() == nil {
+				continue
+			}
+
+			client, err := Dial(ctx, e.addr, p.config.Client)
+
+			p.mu.Lock()
+			if err != nil {
+				pc.err = err
+			} else if p.closed {
+				client.Close()
+			} else {
+				pc.client = client
+				pc.missed = 0
+				pc.err = nil
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// check runs the health checks until the pool is closed.
+func (p *Pool) check() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(p.ctx, p.config.CheckInterval)
+		p.connect(ctx)
+		cancel()
+
+		if p.config.Echo {
+			p.echo()
+		}
+	}
+}
+
+// echo sends an echo test on every connection, counting consecutive
+// failures.
+func (p *Pool) echo() {
+	for _, e := range p.endpoints {
+		for _, pc := range e.conns {
+			p.mu.Lock()
+			client := pc.client
+			p.mu.Unlock()
+
+			if client == nil || client.Err() != nil {
+				continue
+			}
+
+			ctx, cancel := client.networkContext(p.ctx)
+			err := client.Echo(ctx)
+			cancel()
+
+			p.mu.Lock()
+			if err != nil {
+				pc.missed++
+			} else {
+				pc.missed = 0
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Endpoints returns the state of the endpoints.
+func (p *Pool) Endpoints() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := make([]EndpointStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		status[i].Addr = e.addr
+		for _, pc := range e.conns {
+			if pc.usable(p.config.MaxMissedEchoes) {
+				status[i].Conns++
+			}
+			status[i].InFlight += int(pc.inFlight.Load())
+		}
+		status[i].Healthy = status[i].Conns > 0
+	}
+	return status
+}
+
+// Close stops the health checks and closes all connections.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.cancel()
+
+	var clients []*Client
+	for _, e := range p.endpoints {
+		for _, pc := range e.conns {
+			if pc.client != nil {
+				clients = append(clients, pc.client)
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	for _, client := range clients {
+		client.Close()
+	}
+	return nil
+}
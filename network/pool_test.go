@@ -0,0 +1,227 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"iso8583"
+)
+
+// testHost is a server on a local port counting the requests it serves.
+type testHost struct {
+	server   *Server
+	addr     string
+	mu       sync.Mutex
+	requests int
+}
+
+// newTestHost starts a server approving 0200 requests and, with
+// network management, answering 0800 requests.
+func newTestHost(t *testing.T, networkManagement bool) *testHost {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	h := &testHost{addr: ln.Addr().String()}
+
+	mux := NewMux()
+	mux.HandleFunc("0200", func(req *Request) (*iso8583.MessageBuilder, error) {
+		h.mu.Lock()
+		h.requests++
+		h.mu.Unlock()
+		return approve(req)
+	})
+
+	h.server = NewServer(ServerConfig{Handler: mux, NetworkManagement: networkManagement})
+	go h.server.Serve(ln)
+
+	return h
+}
+
+func (h *testHost) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	hosts := []*testHost{newTestHost(t, false), newTestHost(t, false)}
+	for _, h := range hosts {
+		defer h.server.Close()
+	}
+
+	pool, err := NewPool(context.Background(), PoolConfig{
+		Endpoints:        []string{hosts[0].addr, hosts[1].addr},
+		ConnsPerEndpoint: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	for i, status := range pool.Endpoints() {
+		if !status.Healthy || status.Conns != 2 {
+			t.Errorf("Endpoint %d: expected 2 healthy connections, got %+v", i, status)
+		}
+	}
+
+	for i := 1; i <= 8; i++ {
+		if _, err := pool.Send(context.Background(), newTestRequest(fmt.Sprintf("%06d", i))); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	for i, h := range hosts {
+		if h.count() != 4 {
+			t.Errorf("Host %d: expected 4 requests, got %d", i, h.count())
+		}
+	}
+}
+
+func TestPoolLeastInFlight(t *testing.T) {
+	e := &endpoint{addr: "test"}
+	busy := &poolConn{endpoint: e, client: &Client{}}
+	idle := &poolConn{endpoint: e, client: &Client{}}
+	busy.inFlight.Store(3)
+	idle.inFlight.Store(1)
+	e.conns = []*poolConn{busy, idle}
+
+	pool := &Pool{config: PoolConfig{Balancer: LeastInFlight, MaxMissedEchoes: DefaultMaxMissedEchoes}, endpoints: []*endpoint{e}}
+
+	for i := 0; i < 3; i++ {
+		if pc, client := pool.pick(nil); pc != idle || client != idle.client {
+			t.Errorf("Expected connection with fewest requests in flight")
+		}
+	}
+	if pc, _ := pool.pick(map[*poolConn]bool{idle: true}); pc != busy {
+		t.Errorf("Expected the remaining connection when the other was tried")
+	}
+}
+
+func TestPoolEndpointHealthFromSlots(t *testing.T) {
+	e := &endpoint{addr: "test"}
+	live := &poolConn{endpoint: e, client: &Client{}}
+	failed := &poolConn{endpoint: e, err: errors.New("connection refused")}
+	missed := &poolConn{endpoint: e, client: &Client{}, missed: DefaultMaxMissedEchoes}
+	e.conns = []*poolConn{live, failed, missed}
+
+	pool := &Pool{config: PoolConfig{MaxMissedEchoes: DefaultMaxMissedEchoes}, endpoints: []*endpoint{e}}
+
+	if status := pool.Endpoints()[0]; !status.Healthy || status.Conns != 1 {
+		t.Errorf("Expected endpoint healthy with one connection, got %+v", status)
+	}
+
+	for i := 0; i < 3; i++ {
+		if pc, _ := pool.pick(nil); pc != live {
+			t.Errorf("Expected only the live connection to be picked")
+		}
+	}
+
+	live.missed = DefaultMaxMissedEchoes
+	if status := pool.Endpoints()[0]; status.Healthy {
+		t.Errorf("Expected endpoint without usable connections to be unhealthy, got %+v", status)
+	}
+}
+
+func TestPoolFailover(t *testing.T) {
+	hosts := []*testHost{newTestHost(t, false), newTestHost(t, false)}
+	defer hosts[1].server.Close()
+
+	pool, err := NewPool(context.Background(), PoolConfig{
+		Endpoints: []string{hosts[0].addr, hosts[1].addr},
+	})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	hosts[0].server.Close()
+
+	// Wait for the client to notice the connection was closed
+	client := pool.endpoints[0].conns[0].client
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Expected connection to the first host to close")
+	}
+
+	for i := 1; i <= 4; i++ {
+		if _, err := pool.Send(context.Background(), newTestRequest(fmt.Sprintf("%06d", i))); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if hosts[1].count() != 4 {
+		t.Errorf("Expected all requests on the second host, got %d", hosts[1].count())
+	}
+
+	status := pool.Endpoints()
+	if status[0].Healthy || !status[1].Healthy {
+		t.Errorf("Expected only the second endpoint to be healthy, got %+v", status)
+	}
+
+	hosts[1].server.Close()
+	<-pool.endpoints[1].conns[0].client.Done()
+
+	_, err = pool.Send(context.Background(), newTestRequest("000005"))
+	if !errors.Is(err, ErrNoConnection) {
+		t.Errorf("Expected ErrNoConnection, got %v", err)
+	}
+	if err == ErrNoConnection {
+		t.Errorf("Expected ErrNoConnection to wrap the connection failure")
+	}
+}
+
+func TestPoolMissedEchoes(t *testing.T) {
+	// Without network management, echo tests are never answered
+	silent := newTestHost(t, false)
+	defer silent.server.Close()
+	healthy := newTestHost(t, true)
+	defer healthy.server.Close()
+
+	pool, err := NewPool(context.Background(), PoolConfig{
+		Endpoints:       []string{silent.addr, healthy.addr},
+		Client:          ClientConfig{NetworkTimeout: 10 * time.Millisecond},
+		CheckInterval:   20 * time.Millisecond,
+		Echo:            true,
+		MaxMissedEchoes: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.Endpoints()[0].Healthy && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	status := pool.Endpoints()
+	if status[0].Healthy {
+		t.Errorf("Expected endpoint missing echo tests to be unhealthy")
+	}
+	if !status[1].Healthy {
+		t.Errorf("Expected endpoint answering echo tests to be healthy")
+	}
+}
+
+func TestPoolNoEndpoint(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	_, err := NewPool(context.Background(), PoolConfig{Endpoints: []string{addr}})
+	if !errors.Is(err, ErrNoConnection) {
+		t.Errorf("Expected ErrNoConnection, got %v", err)
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Errorf("Expected the dial error to be wrapped, got %v", err)
+	}
+}
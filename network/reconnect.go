@@ -0,0 +1,332 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"iso8583"
+)
+
+var (
+	// ErrDisconnected is returned when sending while disconnected
+	// without an outbound queue.
+	ErrDisconnected = errors.New("disconnected")
+	// ErrQueueFull is returned when sending while disconnected with the
+	// outbound queue full.
+	ErrQueueFull = errors.New("outbound queue full")
+	// ErrQueueExpired is returned when a request waited in the outbound
+	// queue longer than the queue timeout.
+	ErrQueueExpired = errors.New("outbound queue timeout")
+)
+
+// ConnState is the state of the connection of a ReconnectingClient.
+type ConnState int
+
+// List of connection states.
+const (
+	StateConnecting ConnState = iota
+	StateConnected
+	StateDisconnected
+	StateClosed
+)
+
+// String returns the name of the state.
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// Backoff computes the delays between reconnection attempts, growing
+// exponentially up to a maximum with random jitter.
+type Backoff struct {
+	Initial    time.Duration // First delay, 1 second if zero
+	Max        time.Duration // Maximum delay, 1 minute if zero
+	Multiplier float64       // Growth factor, 2 if zero
+	Jitter     float64       // Random variation as a fraction of the delay, e.g. 0.2
+}
+
+// DefaultBackoff is the backoff used without an explicit one.
+var DefaultBackoff = Backoff{Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.2}
+
+// Delay returns the delay before the given attempt, starting at 0.
+func (b Backoff) Delay(attempt int) time.Duration {
+	initial, max, multiplier := b.Initial, b.Max, b.Multiplier
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if max <= 0 {
+		max = time.Minute
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := math.Min(float64(initial)*math.Pow(multiplier, float64(attempt)), float64(max))
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// ReconnectConfig configures a ReconnectingClient.
+type ReconnectConfig struct {
+	// Addr is the address of the host.
+	Addr string
+	// Dial connects to the host, dialing Addr if nil.
+	Dial func(ctx context.Context) (net.Conn, error)
+	// DialTimeout limits each connection attempt, including the sign-on,
+	// 10 seconds if zero.
+	DialTimeout time.Duration
+	// Client configures each connection.
+	Client ClientConfig
+	// Backoff computes the delays between attempts, DefaultBackoff if
+	// zero. The client also waits before reconnecting after losing a
+	// connection.
+	Backoff Backoff
+	// MinUptime is how long a connection must stay up for the backoff
+	// to start over from its initial delay, 1 minute if zero.
+	MinUptime time.Duration
+	// QueueSize is the number of requests that can wait for a connection
+	// while disconnected. Zero fails requests at once with
+	// ErrDisconnected.
+	QueueSize int
+	// QueueTimeout is the longest a request waits for a connection,
+	// unlimited if zero. Requests also stop waiting when their context is
+	// done.
+	QueueTimeout time.Duration
+	// StateChange is called with each new connection state and, on
+	// disconnection, the reason.
+	StateChange func(state ConnState, err error)
+}
+
+// ReconnectingClient is a client that reconnects to the host whenever
+// the connection drops, holding requests back while disconnected if
+// configured to. It is safe for concurrent use.
+type ReconnectingClient struct {
+	config ReconnectConfig
+	ctx    context.Context // Canceled on Close
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu        sync.Mutex
+	client    *Client
+	state     ConnState
+	connected chan struct{} // Closed when connected
+	queued    int
+}
+
+// NewReconnectingClient returns a client connecting to the host in the
+// background.
+func NewReconnectingClient(config ReconnectConfig) *ReconnectingClient {
+	if config.Backoff == (Backoff{}) {
+		config.Backoff = DefaultBackoff
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 10 * time.Second
+	}
+	if config.MinUptime <= 0 {
+		config.MinUptime = time.Minute
+	}
+
+	r := &ReconnectingClient{
+		config:    config,
+		done:      make(chan struct{}),
+		state:     StateDisconnected,
+		connected: make(chan struct{}),
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+
+	go r.run()
+
+	return r
+}
+
+// run connects and reconnects until the client is closed.
+func (r *ReconnectingClient) run() {
+	defer close(r.done)
+
+	for attempt := 0; ; attempt++ {
+		r.setState(StateConnecting, nil, nil)
+
+		client, err := r.dial()
+		if err == nil {
+			connectedAt := time.Now()
+			r.setState(StateConnected, client, nil)
+
+			select {
+			case <-client.Done():
+				err = client.Err()
+			case <-r.ctx.Done():
+				client.Close()
+			}
+
+			// Hosts that drop connections at once are not redialed in a loop
+			if time.Since(connectedAt) >= r.config.MinUptime {
+				attempt = 0
+			}
+		}
+
+		if r.ctx.Err() != nil {
+			r.setState(StateClosed, nil, nil)
+			return
+		}
+		r.setState(StateDisconnected, nil, err)
+
+		select {
+		case <-r.ctx.Done():
+			r.setState(StateClosed, nil, nil)
+			return
+		case <-time.After(r.config.Backoff.Delay(attempt)):
+		}
+	}
+}
+
+// dial connects to the host and signs on if configured.
+func (r *ReconnectingClient) dial() (*Client, error) {
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.DialTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if r.config.Dial != nil {
+		conn, err = r.config.Dial(ctx)
+	} else {
+		conn, err = dialConn(ctx, r.config.Addr, r.config.Client.TLS)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return connect(ctx, conn, r.config.Client)
+}
+
+// setState records the connection state and notifies StateChange.
+func (r *ReconnectingClient) setState(state ConnState, client *Client, err error) {
+	r.mu.Lock()
+	changed := state != r.state
+	r.state = state
+	r.client = client
+	if state == StateConnected {
+		close(r.connected)
+	} else if client == nil && isClosed(r.connected) {
+		r.connected = make(chan struct{})
+	}
+	r.mu.Unlock()
+
+	if changed && r.config.StateChange != nil {
+		r.config.StateChange(state, err)
+	}
+}
+
+// isClosed reports whether the channel is closed.
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// State returns the connection state.
+func (r *ReconnectingClient) State() ConnState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// Send sends a request and waits for its response until ctx is done.
+// While disconnected, the request waits for a connection in the
+// outbound queue if there is room.
+func (r *ReconnectingClient) Send(ctx context.Context, request *iso8583.MessageBuilder) (*iso8583.Parser, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		if r.ctx.Err() != nil {
+			r.mu.Unlock()
+			return nil, ErrClosed
+		}
+
+		client := r.client
+		if client != nil && client.Err() == nil {
+			r.mu.Unlock()
+
+			response, err := client.Send(ctx, request)
+
+			// Wait for the next connection if this one dropped first
+			var notSent notSentError
+			if err != nil && errors.As(err, &notSent) && client.Err() != nil {
+				continue
+			}
+			return response, err
+		}
+
+		if err := r.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait waits in the outbound queue for a connection. It is called with
+// the lock held and releases it.
+func (r *ReconnectingClient) wait(ctx context.Context) error {
+	if r.queued >= r.config.QueueSize {
+		r.mu.Unlock()
+		if r.config.QueueSize == 0 {
+			return ErrDisconnected
+		}
+		return ErrQueueFull
+	}
+	r.queued++
+	connected := r.connected
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.queued--
+		r.mu.Unlock()
+	}()
+
+	var expired <-chan time.Time
+	if r.config.QueueTimeout > 0 {
+		timer := time.NewTimer(r.config.QueueTimeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-connected:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-expired:
+		return ErrQueueExpired
+	case <-r.ctx.Done():
+		return ErrClosed
+	}
+}
+
+// Close closes the connection and stops reconnecting.
+func (r *ReconnectingClient) Close() error {
+	r.cancel()
+	<-r.done
+	return nil
+}
@@ -0,0 +1,266 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pipeHost serves the connections of a reconnecting client over pipes.
+type pipeHost struct {
+	server *Server
+
+	mu    sync.Mutex
+	conns []net.Conn
+	ready chan struct{} // Dials block until ready is closed
+}
+
+func newPipeHost() *pipeHost {
+	mux := NewMux()
+	mux.HandleFunc("0200", approve)
+
+	ready := make(chan struct{})
+	close(ready)
+
+	return &pipeHost{server: NewServer(ServerConfig{Handler: mux}), ready: ready}
+}
+
+func (h *pipeHost) dial(ctx context.Context) (net.Conn, error) {
+	h.mu.Lock()
+	ready := h.ready
+	h.mu.Unlock()
+
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	clientConn, serverConn := net.Pipe()
+	go h.server.ServeConn(serverConn)
+
+	h.mu.Lock()
+	h.conns = append(h.conns, serverConn)
+	h.mu.Unlock()
+
+	return clientConn, nil
+}
+
+// drop closes the current connection, holding new ones until the
+// returned function is called.
+func (h *pipeHost) drop() func() {
+	ready := make(chan struct{})
+
+	h.mu.Lock()
+	h.ready = ready
+	for _, conn := range h.conns {
+		conn.Close()
+	}
+	h.conns = nil
+	h.mu.Unlock()
+
+	return func() { close(ready) }
+}
+
+// waitState waits for the client to reach the state.
+func waitState(t *testing.T, r *ReconnectingClient, state ConnState) {
+	deadline := time.Now().Add(2 * time.Second)
+	for r.State() != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected state %s, got %s", state, r.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	host := newPipeHost()
+	defer host.server.Close()
+
+	var mu sync.Mutex
+	var states []ConnState
+
+	r := NewReconnectingClient(ReconnectConfig{
+		Dial:    host.dial,
+		Backoff: Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond},
+		StateChange: func(state ConnState, err error) {
+			mu.Lock()
+			states = append(states, state)
+			mu.Unlock()
+		},
+	})
+
+	waitState(t, r, StateConnected)
+	if _, err := r.Send(context.Background(), newTestRequest("000001")); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	release := host.drop()
+	waitState(t, r, StateConnecting)
+
+	if _, err := r.Send(context.Background(), newTestRequest("000002")); !errors.Is(err, ErrDisconnected) {
+		t.Errorf("Expected ErrDisconnected without queue, got %v", err)
+	}
+
+	release()
+	waitState(t, r, StateConnected)
+
+	if _, err := r.Send(context.Background(), newTestRequest("000003")); err != nil {
+		t.Fatalf("Send() after reconnect error = %v", err)
+	}
+
+	r.Close()
+	if _, err := r.Send(context.Background(), newTestRequest("000004")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []ConnState{StateConnecting, StateConnected, StateDisconnected, StateConnecting, StateConnected, StateClosed}
+	if len(states) != len(expected) {
+		t.Fatalf("Expected states %v, got %v", expected, states)
+	}
+	for i := range expected {
+		if states[i] != expected[i] {
+			t.Errorf("State %d: expected %s, got %s", i, expected[i], states[i])
+		}
+	}
+}
+
+func TestReconnectBacksOffAfterDrop(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	var mu sync.Mutex
+	accepted := 0
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepted++
+			mu.Unlock()
+			conn.Close()
+		}
+	}()
+
+	r := NewReconnectingClient(ReconnectConfig{
+		Addr:    ln.Addr().String(),
+		Backoff: Backoff{Initial: 20 * time.Millisecond, Max: 20 * time.Millisecond},
+	})
+	time.Sleep(200 * time.Millisecond)
+	r.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if accepted < 2 || accepted > 12 {
+		t.Errorf("Expected about one dial every 20ms over 200ms, got %d", accepted)
+	}
+}
+
+func TestReconnectQueue(t *testing.T) {
+	host := newPipeHost()
+	defer host.server.Close()
+	release := host.drop()
+
+	r := NewReconnectingClient(ReconnectConfig{
+		Dial:         host.dial,
+		Backoff:      Backoff{Initial: time.Millisecond},
+		QueueSize:    1,
+		QueueTimeout: time.Second,
+	})
+	defer r.Close()
+
+	sent := make(chan error, 1)
+	go func() {
+		_, err := r.Send(context.Background(), newTestRequest("000001"))
+		sent <- err
+	}()
+
+	// Wait for the request to be queued
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.mu.Lock()
+		queued := r.queued
+		r.mu.Unlock()
+		if queued == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := r.Send(context.Background(), newTestRequest("000002")); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	release()
+
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Errorf("Expected queued request to be sent after connecting, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected queued request to be sent")
+	}
+}
+
+func TestReconnectQueueExpiry(t *testing.T) {
+	host := newPipeHost()
+	defer host.server.Close()
+	release := host.drop()
+	defer release()
+
+	r := NewReconnectingClient(ReconnectConfig{
+		Dial:         host.dial,
+		QueueSize:    2,
+		QueueTimeout: 20 * time.Millisecond,
+	})
+	defer r.Close()
+
+	if _, err := r.Send(context.Background(), newTestRequest("000001")); !errors.Is(err, ErrQueueExpired) {
+		t.Errorf("Expected ErrQueueExpired, got %v", err)
+	}
+
+	// Without a queue timeout, requests fail as soon as their deadline passes
+	r = NewReconnectingClient(ReconnectConfig{Dial: host.dial, QueueSize: 2})
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := r.Send(ctx, newTestRequest("000002")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected request to fail at its deadline, took %s", elapsed)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for attempt, ms := range expected {
+		if delay := b.Delay(attempt); delay != ms*time.Millisecond {
+			t.Errorf("Attempt %d: expected %s, got %s", attempt, ms*time.Millisecond, delay)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := b.Delay(1)
+		if delay < 100*time.Millisecond || delay > 300*time.Millisecond {
+			t.Errorf("Expected jittered delay between 100ms and 300ms, got %s", delay)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"iso8583"
+	"iso8583/framing"
+)
+
+// ErrServerClosed is returned by Serve after Shutdown or Close.
+var ErrServerClosed = errors.New("server closed")
+
+// Request is a message received by a Server.
+type Request struct {
+	Message    *iso8583.Parser
+	Header     framing.Header // Header of the frame, e.g. with the TPDU
+	Raw        []byte         // Message as received
+	RemoteAddr net.Addr
+	SignedOn   bool                 // Whether the connection is signed on
+	TLS        *tls.ConnectionState // State of TLS connections, nil otherwise
+
+	ctx context.Context
+}
+
+// PeerCertificate returns the certificate the client authenticated with
+// on mutual TLS connections, or nil.
+func (r *Request) PeerCertificate() *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return r.TLS.PeerCertificates[0]
+}
+
+// Context returns the context of the request, canceled when the
+// connection closes.
+func (r *Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// Handler responds to requests. A nil response sends nothing back.
+type Handler interface {
+	ServeISO(req *Request) (*iso8583.MessageBuilder, error)
+}
+
+// HandlerFunc adapts a function to a Handler.
+type HandlerFunc func(req *Request) (*iso8583.MessageBuilder, error)
+
+// ServeISO calls f(req).
+func (f HandlerFunc) ServeISO(req *Request) (*iso8583.MessageBuilder, error) {
+	return f(req)
+}
+
+// ServerConfig configures a Server.
+type ServerConfig struct {
+	// Spec used to parse requests, DefaultSpec if nil.
+	Spec *iso8583.Spec
+	// Framer used to read and write messages, a 2 byte binary length
+	// header if nil.
+	Framer *framing.Framer
+	// Handler responds to requests, typically a Mux.
+	Handler Handler
+	// Error receives errors parsing requests, from handlers and writing
+	// responses.
+	Error func(err error)
+
+	// NetworkManagement answers 0800 requests with 0810 instead of
+	// passing them to the Handler, tracking sign-on and sign-off per
+	// connection.
+	NetworkManagement bool
+	// RequireSignOn declines requests other than network management
+	// with response code 91 on connections not signed on. It requires
+	// NetworkManagement.
+	RequireSignOn bool
+
+	// TLS serves connections over TLS. See TLSOptions.
+	TLS *tls.Config
+
+	// Middleware wraps every handler, including those answering network
+	// management and declining requests, the first outermost.
+	Middleware []Middleware
+
+	// Limits restricts the requests handled at once and their rate on
+	// each connection, except network management requests. Requests over
+	// the limits are declined with response code 91 and an ErrLimited
+	// error or, with Block, wait after being read, holding back the next
+	// requests of the connection.
+	Limits Limits
+}
+
+func (c ServerConfig) withDefaults() ServerConfig {
+	if c.Spec == nil {
+		c.Spec = iso8583.DefaultSpec
+	}
+	if c.Framer == nil {
+		c.Framer = framing.New(framing.Binary2)
+	}
+	if c.Handler == nil {
+		c.Handler = NewMux()
+	}
+	return c
+}
+
+// Server accepts connections and serves the requests received on them,
+// each in its own goroutine, writing the responses back in the order
+// they are ready.
+type Server struct {
+	config ServerConfig
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*serverConn]struct{}
+	closed    bool
+	connsDone sync.WaitGroup
+}
+
+// NewServer returns a server with the given configuration.
+func NewServer(config ServerConfig) *Server {
+	return &Server{
+		config:    config.withDefaults(),
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[*serverConn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP address and serves connections.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on the listener until it fails or the server
+// is shut down, returning ErrServerClosed in the latter case.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ln.Close()
+		return ErrServerClosed
+	}
+	s.listeners[ln] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, ln)
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn serves the requests received on conn until it closes or the
+// server is shut down. With TLS configured, the server side of the TLS
+// handshake is performed on conn first.
+func (s *Server) ServeConn(conn net.Conn) {
+	if _, ok := conn.(*tls.Conn); !ok && s.config.TLS != nil {
+		conn = tls.Server(conn, s.config.TLS)
+	}
+
+	c := &serverConn{server: s, conn: conn, limiter: newLimiter(s.config.Limits)}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	s.conns[c] = struct{}{}
+	s.connsDone.Add(1)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		s.connsDone.Done()
+	}()
+
+	c.serve()
+}
+
+// Shutdown stops accepting connections and reading requests, then waits
+// for the requests in flight to be answered before closing the
+// connections. If ctx is done first, the connections are closed at once.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	for ln := range s.listeners {
+		ln.Close()
+	}
+	for c := range s.conns {
+		// Unblock the read loop without interrupting responses
+		c.conn.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.connsDone.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+}
+
+// Close closes the listeners and connections at once.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for ln := range s.listeners {
+		ln.Close()
+	}
+	for c := range s.conns {
+		c.cancel()
+		c.conn.Close()
+	}
+	return nil
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) error(err error) {
+	if s.config.Error != nil {
+		s.config.Error(err)
+	}
+}
+
+// serverConn is a connection served by a Server.
+type serverConn struct {
+	server  *Server
+	conn    net.Conn
+	limiter *limiter
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	writeMu  sync.Mutex
+	handlers sync.WaitGroup
+	signedOn atomic.Bool
+	tls      *tls.ConnectionState
+}
+
+// serve reads requests until the connection fails or the server shuts
+// down, then waits for the handlers before closing the connection.
+func (c *serverConn) serve() {
+	defer func() {
+		c.handlers.Wait()
+		c.cancel()
+		c.conn.Close()
+	}()
+
+	if tlsConn, ok := c.conn.(*tls.Conn); ok {
+		if err := tlsConn.HandshakeContext(c.ctx); err != nil {
+			c.server.error(fmt.Errorf("TLS handshake with %s failed: %w", c.conn.RemoteAddr(), err))
+			return
+		}
+		state := tlsConn.ConnectionState()
+		c.tls = &state
+	}
+
+	for {
+		frame, err := c.server.config.Framer.ReadFrame(c.conn)
+		if err != nil {
+			return
+		}
+
+		release := func() {}
+		var limitErr error
+
+		// Network management requests are not limited, as on clients
+		if !isNetworkManagement(frameMTI(frame)) {
+			if release, limitErr = c.limiter.acquire(c.ctx); limitErr != nil {
+				release = func() {}
+			}
+		}
+
+		c.handlers.Add(1)
+		go func() {
+			defer c.handlers.Done()
+			defer release()
+			c.handle(frame, limitErr)
+		}()
+	}
+}
+
+// frameMTI returns the MTI at the start of a frame, or "" if the frame
+// is too short.
+func frameMTI(frame framing.Frame) string {
+	if len(frame.Message) < 4 {
+		return ""
+	}
+	return string(frame.Message[:4])
+}
+
+// handle serves a request and writes its response. Requests rejected by
+// the limits are declined.
+func (c *serverConn) handle(frame framing.Frame, limitErr error) {
+	s := c.server
+
+	msg, err := iso8583.NewParserWithSpec(s.config.Spec).Parse(string(frame.Message))
+	if err != nil {
+		s.error(fmt.Errorf("error parsing request from %s: %w", c.conn.RemoteAddr(), err))
+		return
+	}
+
+	req := &Request{
+		Message:    msg,
+		Header:     frame.Header,
+		Raw:        frame.Message,
+		RemoteAddr: c.conn.RemoteAddr(),
+		SignedOn:   c.signedOn.Load(),
+		TLS:        c.tls,
+		ctx:        c.ctx,
+	}
+
+	handler := s.config.Handler
+	if limitErr != nil {
+		s.error(fmt.Errorf("request %s from %s rejected: %w", msg.MTI, c.conn.RemoteAddr(), limitErr))
+		handler = HandlerFunc(declineLimited)
+	} else if s.config.NetworkManagement {
+		switch {
+		case isNetworkManagement(msg.MTI) && !isResponse(msg.MTI):
+			handler = HandlerFunc(c.serveNetworkRequest)
+		case s.config.RequireSignOn && !req.SignedOn:
+			handler = HandlerFunc(declineNotSignedOn)
+		}
+	}
+
+	response, err := Chain(handler, s.config.Middleware...).ServeISO(req)
+	if err != nil {
+		s.error(fmt.Errorf("error handling %s: %w", msg.MTI, err))
+		return
+	}
+	if response == nil {
+		return
+	}
+
+	if err := c.writeResponse(req, response); err != nil {
+		s.error(fmt.Errorf("error writing response to %s: %w", msg.MTI, err))
+	}
+}
+
+// writeResponse builds the response and writes it with the reply header
+// of the request.
+func (c *serverConn) writeResponse(req *Request, response *iso8583.MessageBuilder) error {
+	raw, err := response.Build()
+	if err != nil {
+		return err
+	}
+
+	var header framing.Header
+	if req.Header.TPDU != nil {
+		reply := req.Header.TPDU.Reply()
+		header.TPDU = &reply
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.server.config.Framer.WriteFrame(c.conn, framing.Frame{Header: header, Message: []byte(raw)})
+}
+
+// decline returns the response to a request with response code 91, or
+// reason if the request cannot be answered.
+func decline(req *Request, reason error) (*iso8583.MessageBuilder, error) {
+	response, err := iso8583.NewResponse(req.Message)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", reason, err)
+	}
+	response.AddField(39, "91")
+	return response, nil
+}
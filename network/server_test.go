@@ -0,0 +1,191 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"iso8583"
+	"iso8583/framing"
+)
+
+// approve responds to a request with response code 00.
+func approve(req *Request) (*iso8583.MessageBuilder, error) {
+	response, err := iso8583.NewResponse(req.Message)
+	if err != nil {
+		return nil, err
+	}
+	response.AddField(39, "00")
+	return response, nil
+}
+
+func TestServerServeConn(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("0200", approve)
+
+	server := NewServer(ServerConfig{Handler: mux})
+	defer server.Close()
+
+	clientConn, serverConn := net.Pipe()
+	go server.ServeConn(serverConn)
+
+	client := NewClient(clientConn, ClientConfig{})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	response, err := client.Send(ctx, newTestRequest("000001"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.MTI != "0210" || response.Fields[39] != "00" {
+		t.Errorf("Expected approved 0210, got %s with response code %s", response.MTI, response.Fields[39])
+	}
+}
+
+func TestServerTPDUReply(t *testing.T) {
+	f := framing.New(framing.TPDUHeader{})
+
+	mux := NewMux()
+	mux.HandleFunc("0200", approve)
+
+	server := NewServer(ServerConfig{Framer: f, Handler: mux})
+	defer server.Close()
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeConn(serverConn)
+
+	tpdu, _ := framing.ParseTPDU("6000030000")
+	raw, _ := newTestRequest("000001").Build()
+	go f.WriteFrame(clientConn, framing.Frame{Header: framing.Header{TPDU: &tpdu}, Message: []byte(raw)})
+
+	frame, err := f.ReadFrame(clientConn)
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v", err)
+	}
+	if frame.Header.TPDU == nil || frame.Header.TPDU.String() != "6000000003" {
+		t.Errorf("Expected reply TPDU 6000000003, got %v", frame.Header.TPDU)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	errs := make(chan error, 2)
+	server := NewServer(ServerConfig{Error: func(err error) { errs <- err }})
+	defer server.Close()
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeConn(serverConn)
+
+	f := framing.New(framing.Binary2)
+	f.WriteMessage(clientConn, "02")
+
+	raw, _ := newTestRequest("000001").Build()
+	f.WriteMessage(clientConn, raw)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if err == nil {
+				t.Errorf("Expected error")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected errors for invalid and unhandled requests")
+		}
+	}
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := NewMux()
+	mux.HandleFunc("0200", func(req *Request) (*iso8583.MessageBuilder, error) {
+		close(started)
+		<-release
+		return approve(req)
+	})
+
+	server := NewServer(ServerConfig{Handler: mux})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ln) }()
+
+	client, err := Dial(context.Background(), ln.Addr().String(), ClientConfig{})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		response, err := client.Send(context.Background(), newTestRequest("000001"))
+		if err != nil {
+			t.Errorf("Expected in-flight request to be answered, got %v", err)
+			return
+		}
+		if response.Fields[39] != "00" {
+			t.Errorf("Expected response code 00, got %s", response.Fields[39])
+		}
+	}()
+
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- server.Shutdown(context.Background()) }()
+
+	if err := <-served; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed, got %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Errorf("Expected connection to be closed after shutdown")
+	}
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("0200", func(req *Request) (*iso8583.MessageBuilder, error) {
+		<-req.Context().Done()
+		return nil, nil
+	})
+
+	server := NewServer(ServerConfig{Handler: mux})
+
+	clientConn, serverConn := net.Pipe()
+	go server.ServeConn(serverConn)
+
+	client := NewClient(clientConn, ClientConfig{})
+	defer client.Close()
+
+	go client.Send(context.Background(), newTestRequest("000001"))
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+}
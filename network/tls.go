@@ -0,0 +1,102 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSOptions describes the certificates used on TLS connections and
+// builds the matching tls.Config for clients and servers.
+type TLSOptions struct {
+	// CertFile and KeyFile hold the PEM certificate and key presented to
+	// the peer. Clients only need them for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// CAFile holds the PEM certificates of the CAs the peer certificate
+	// is verified against. Clients use the system pool if empty.
+	CAFile string
+	// ServerName is the name the client verifies the server certificate
+	// against, the host of the dialed address if empty.
+	ServerName string
+	// ClientAuth makes servers require and verify client certificates
+	// (mutual TLS), which requires CAFile.
+	ClientAuth bool
+	// MinVersion is the minimum TLS version, TLS 1.2 if zero.
+	MinVersion uint16
+}
+
+// ClientConfig returns the TLS configuration of a client.
+func (o TLSOptions) ClientConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: o.ServerName,
+		MinVersion: o.minVersion(),
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.CAFile != "" {
+		pool, err := loadCertPool(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// ServerConfig returns the TLS configuration of a server.
+func (o TLSOptions) ServerConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading server certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   o.minVersion(),
+	}
+
+	if o.ClientAuth {
+		if o.CAFile == "" {
+			return nil, fmt.Errorf("client authentication requires a CA file")
+		}
+		pool, err := loadCertPool(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+func (o TLSOptions) minVersion() uint16 {
+	if o.MinVersion == 0 {
+		return tls.VersionTLS12
+	}
+	return o.MinVersion
+}
+
+// loadCertPool returns a pool with the PEM certificates of the file.
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
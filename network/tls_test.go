@@ -0,0 +1,249 @@
+package network
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"iso8583"
+)
+
+// testCA issues certificates for TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+// newTestCA creates a CA and writes its certificate to dir.
+func newTestCA(t *testing.T, dir string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	file := filepath.Join(dir, "ca.pem")
+	writePEM(t, file, "CERTIFICATE", der)
+
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// issue creates a certificate signed by the CA and writes it with its
+// key to dir, returning the file names.
+func (ca *testCA) issue(t *testing.T, dir, name string, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+// newTLSServer starts a server with mutual TLS, reporting the common
+// name of the client certificate of each request.
+func newTLSServer(t *testing.T, options TLSOptions, peers chan<- string) (*Server, string) {
+	config, err := options.ServerConfig()
+	if err != nil {
+		t.Fatalf("ServerConfig() error = %v", err)
+	}
+
+	mux := NewMux()
+	mux.HandleFunc("0200", func(req *Request) (*iso8583.MessageBuilder, error) {
+		if cert := req.PeerCertificate(); cert != nil {
+			peers <- cert.Subject.CommonName
+		} else {
+			peers <- ""
+		}
+		return approve(req)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	server := NewServer(ServerConfig{Handler: mux, TLS: config})
+	go server.Serve(ln)
+
+	return server, ln.Addr().String()
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "host.test", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, dir, "acquirer-1", x509.ExtKeyUsageClientAuth)
+
+	peers := make(chan string, 1)
+	server, addr := newTLSServer(t, TLSOptions{
+		CertFile:   serverCert,
+		KeyFile:    serverKey,
+		CAFile:     ca.file,
+		ClientAuth: true,
+		MinVersion: tls.VersionTLS13,
+	}, peers)
+	defer server.Close()
+
+	tlsConfig, err := TLSOptions{
+		CertFile:   clientCert,
+		KeyFile:    clientKey,
+		CAFile:     ca.file,
+		ServerName: "host.test",
+	}.ClientConfig()
+	if err != nil {
+		t.Fatalf("ClientConfig() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := Dial(ctx, addr, ClientConfig{TLS: tlsConfig})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	response, err := client.Send(ctx, newTestRequest("000001"))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if response.Fields[39] != "00" {
+		t.Errorf("Expected response code 00, got %s", response.Fields[39])
+	}
+	if peer := <-peers; peer != "acquirer-1" {
+		t.Errorf("Expected peer acquirer-1, got %q", peer)
+	}
+}
+
+func TestMutualTLSRejectsClientWithoutCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "host.test", x509.ExtKeyUsageServerAuth)
+
+	server, addr := newTLSServer(t, TLSOptions{
+		CertFile:   serverCert,
+		KeyFile:    serverKey,
+		CAFile:     ca.file,
+		ClientAuth: true,
+	}, make(chan string, 1))
+	defer server.Close()
+
+	tlsConfig, err := TLSOptions{CAFile: ca.file, ServerName: "host.test"}.ClientConfig()
+	if err != nil {
+		t.Fatalf("ClientConfig() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// With TLS 1.3 the client learns of the rejection on its first read
+	client, err := Dial(ctx, addr, ClientConfig{TLS: tlsConfig})
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	if _, err := client.Send(ctx, newTestRequest("000001")); err == nil {
+		t.Errorf("Expected request without client certificate to fail")
+	}
+}
+
+func TestTLSRejectsUnknownCA(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "host.test", x509.ExtKeyUsageServerAuth)
+
+	server, addr := newTLSServer(t, TLSOptions{CertFile: serverCert, KeyFile: serverKey}, make(chan string, 1))
+	defer server.Close()
+
+	otherCA := newTestCA(t, t.TempDir())
+	tlsConfig, err := TLSOptions{CAFile: otherCA.file, ServerName: "host.test"}.ClientConfig()
+	if err != nil {
+		t.Fatalf("ClientConfig() error = %v", err)
+	}
+
+	if _, err := Dial(context.Background(), addr, ClientConfig{TLS: tlsConfig}); err == nil {
+		t.Errorf("Expected error dialing server with certificate from unknown CA")
+	}
+}
+
+func TestTLSOptionsErrors(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "host.test", x509.ExtKeyUsageServerAuth)
+
+	if _, err := (TLSOptions{CertFile: serverCert, KeyFile: serverKey, ClientAuth: true}).ServerConfig(); err == nil {
+		t.Errorf("Expected error for client authentication without CA file")
+	}
+	if _, err := (TLSOptions{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: serverKey}).ServerConfig(); err == nil {
+		t.Errorf("Expected error for missing certificate")
+	}
+	if _, err := (TLSOptions{CAFile: serverKey}).ClientConfig(); err == nil {
+		t.Errorf("Expected error for CA file without certificates")
+	}
+
+	config, err := TLSOptions{}.ClientConfig()
+	if err != nil {
+		t.Fatalf("ClientConfig() error = %v", err)
+	}
+	if config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("Expected minimum version TLS 1.2, got %x", config.MinVersion)
+	}
+}
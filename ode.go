@@ -0,0 +1,121 @@
+package iso8583
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// OriginalDataElements is the content of field 90, identifying the
+// original message of a reversal or advice.
+type OriginalDataElements struct {
+	MTI                     string // n 4
+	STAN                    string // n 6
+	TransmissionDateTime    string // n 10, MMDDhhmmss
+	AcquiringInstitutionID  string // n 11
+	ForwardingInstitutionID string // n 11
+}
+
+// OriginalDataElementsOf returns the original data elements identifying
+// a message from its MTI and fields, e.g. those of a MessageBuilder or a
+// Parser.
+func OriginalDataElementsOf(mti string, fields map[int]string) (OriginalDataElements, error) {
+	stan, ok := fields[11]
+	if !ok {
+		return OriginalDataElements{}, fmt.Errorf("original message has no system trace audit number")
+	}
+
+	ode := OriginalDataElements{
+		MTI:                     mti,
+		STAN:                    padOrTruncate(stan, 6, "n"),
+		TransmissionDateTime:    padOrTruncate(fields[7], 10, "n"),
+		AcquiringInstitutionID:  padOrTruncate(fields[32], 11, "n"),
+		ForwardingInstitutionID: padOrTruncate(fields[33], 11, "n"),
+	}
+
+	return ode, ode.Validate()
+}
+
+// ParseOriginalDataElements parses the 42 digit value of field 90.
+func ParseOriginalDataElements(value string) (OriginalDataElements, error) {
+	if len(value) != 42 {
+		return OriginalDataElements{}, fmt.Errorf("original data elements must have 42 digits, got %d", len(value))
+	}
+
+	ode := OriginalDataElements{
+		MTI:                     value[0:4],
+		STAN:                    value[4:10],
+		TransmissionDateTime:    value[10:20],
+		AcquiringInstitutionID:  value[20:31],
+		ForwardingInstitutionID: value[31:42],
+	}
+
+	return ode, ode.Validate()
+}
+
+// OriginalDataElements returns the parsed field 90 of the message.
+func (m *Parser) OriginalDataElements() (OriginalDataElements, error) {
+	value, ok := m.Fields[90]
+	if !ok {
+		return OriginalDataElements{}, fmt.Errorf("message has no original data elements")
+	}
+	return ParseOriginalDataElements(value)
+}
+
+// String formats the original data elements as the 42 digit field 90 value.
+func (o OriginalDataElements) String() string {
+	return o.MTI + o.STAN + o.TransmissionDateTime + o.AcquiringInstitutionID + o.ForwardingInstitutionID
+}
+
+// Validate checks the length and content of each component.
+func (o OriginalDataElements) Validate() error {
+	components := []struct {
+		name   string
+		value  string
+		length int
+	}{
+		{"MTI", o.MTI, 4},
+		{"STAN", o.STAN, 6},
+		{"transmission date & time", o.TransmissionDateTime, 10},
+		{"acquiring institution ID", o.AcquiringInstitutionID, 11},
+		{"forwarding institution ID", o.ForwardingInstitutionID, 11},
+	}
+
+	for _, c := range components {
+		if len(c.value) != c.length || !isNumeric(c.value) {
+			return fmt.Errorf("original %s must have %d digits, got %q", c.name, c.length, c.value)
+		}
+	}
+
+	// A zero date & time means the original did not carry field 7
+	if dt := o.TransmissionDateTime; dt != "0000000000" {
+		month, _ := strconv.Atoi(dt[0:2])
+		day, _ := strconv.Atoi(dt[2:4])
+		hour, _ := strconv.Atoi(dt[4:6])
+		minute, _ := strconv.Atoi(dt[6:8])
+		second, _ := strconv.Atoi(dt[8:10])
+
+		if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 || second > 59 {
+			return fmt.Errorf("invalid original transmission date & time %s", dt)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether o refers to the message identified by
+// original. The forwarding institution is only compared when both
+// carry one, as it is often filled in along the way.
+func (o OriginalDataElements) Matches(original OriginalDataElements) bool {
+	if o.MTI != original.MTI || o.STAN != original.STAN ||
+		o.TransmissionDateTime != original.TransmissionDateTime ||
+		o.AcquiringInstitutionID != original.AcquiringInstitutionID {
+		return false
+	}
+
+	const none = "00000000000"
+	if o.ForwardingInstitutionID == none || original.ForwardingInstitutionID == none {
+		return true
+	}
+
+	return o.ForwardingInstitutionID == original.ForwardingInstitutionID
+}
@@ -0,0 +1,84 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestParseOriginalDataElements(t *testing.T) {
+	ode, err := ParseOriginalDataElements("020000012302091234560000012345600000000000")
+	if err != nil {
+		t.Fatalf("ParseOriginalDataElements() error = %v", err)
+	}
+
+	expected := OriginalDataElements{
+		MTI:                     "0200",
+		STAN:                    "000123",
+		TransmissionDateTime:    "0209123456",
+		AcquiringInstitutionID:  "00000123456",
+		ForwardingInstitutionID: "00000000000",
+	}
+	if ode != expected {
+		t.Errorf("Expected %+v, got %+v", expected, ode)
+	}
+
+	if ode.String() != "020000012302091234560000012345600000000000" {
+		t.Errorf("Unexpected formatting %s", ode.String())
+	}
+
+	for _, invalid := range []string{
+		"0200000123",
+		"02000001230209123456000001234560000000000X",
+		"020000012313091234560000012345600000000000",
+	} {
+		if _, err := ParseOriginalDataElements(invalid); err == nil {
+			t.Errorf("ParseOriginalDataElements(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestOriginalDataElementsMatching(t *testing.T) {
+	original := newOriginalRequest()
+
+	reversal, err := NewReversal(original)
+	if err != nil {
+		t.Fatalf("NewReversal() error = %v", err)
+	}
+
+	raw, err := reversal.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	received, err := parsed.OriginalDataElements()
+	if err != nil {
+		t.Fatalf("OriginalDataElements() error = %v", err)
+	}
+
+	stored, err := OriginalDataElementsOf(original.MTI, original.Fields)
+	if err != nil {
+		t.Fatalf("OriginalDataElementsOf() error = %v", err)
+	}
+
+	if !received.Matches(stored) {
+		t.Errorf("Expected %+v to match %+v", received, stored)
+	}
+
+	stored.ForwardingInstitutionID = "00000654321"
+	if !received.Matches(stored) {
+		t.Errorf("Expected a missing forwarding institution to match any")
+	}
+
+	stored.STAN = "000124"
+	if received.Matches(stored) {
+		t.Errorf("Expected a different STAN not to match")
+	}
+
+	if _, err := NewParser().OriginalDataElements(); err == nil {
+		t.Errorf("Expected error for message without field 90")
+	}
+}
@@ -0,0 +1,435 @@
+package iso8583
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PANEntryMode is the 2 digit PAN entry mode of a 1987 POS entry mode.
+type PANEntryMode string
+
+// List of PAN entry modes.
+const (
+	PANEntryUnknown              PANEntryMode = "00"
+	PANEntryManual               PANEntryMode = "01"
+	PANEntryMagstripe            PANEntryMode = "02"
+	PANEntryChip                 PANEntryMode = "05"
+	PANEntryContactless          PANEntryMode = "07"
+	PANEntryCredentialOnFile     PANEntryMode = "10"
+	PANEntryChipFallback         PANEntryMode = "80"
+	PANEntryEcommerce            PANEntryMode = "81"
+	PANEntryMagstripeFullTrack   PANEntryMode = "90"
+	PANEntryContactlessMagstripe PANEntryMode = "91"
+)
+
+var panEntryModeNames = map[PANEntryMode]string{
+	PANEntryUnknown:              "unknown",
+	PANEntryManual:               "manual key entry",
+	PANEntryMagstripe:            "magnetic stripe",
+	PANEntryChip:                 "chip",
+	PANEntryContactless:          "contactless chip",
+	PANEntryCredentialOnFile:     "credential on file",
+	PANEntryChipFallback:         "chip fallback to magnetic stripe",
+	PANEntryEcommerce:            "e-commerce",
+	PANEntryMagstripeFullTrack:   "magnetic stripe, full track",
+	PANEntryContactlessMagstripe: "contactless magnetic stripe",
+}
+
+// String returns the name of the PAN entry mode.
+func (p PANEntryMode) String() string {
+	if name, ok := panEntryModeNames[p]; ok {
+		return name
+	}
+	return "PAN entry mode " + string(p)
+}
+
+// PINCapability is the 1 digit PIN entry capability of a 1987 POS entry mode.
+type PINCapability string
+
+// List of PIN entry capabilities.
+const (
+	PINCapabilityUnknown PINCapability = "0"
+	PINCapable           PINCapability = "1"
+	PINNotCapable        PINCapability = "2"
+	PINPadDown           PINCapability = "8"
+)
+
+var pinCapabilityNames = map[PINCapability]string{
+	PINCapabilityUnknown: "unknown",
+	PINCapable:           "PIN entry capable",
+	PINNotCapable:        "no PIN entry capability",
+	PINPadDown:           "PIN pad down",
+}
+
+// String returns the name of the PIN entry capability.
+func (p PINCapability) String() string {
+	if name, ok := pinCapabilityNames[p]; ok {
+		return name
+	}
+	return "PIN capability " + string(p)
+}
+
+// POSEntryMode is the 3 digit POS entry mode of field 22 in ISO 8583:1987.
+type POSEntryMode struct {
+	PANEntry      PANEntryMode
+	PINCapability PINCapability
+}
+
+// ParsePOSEntryMode parses a 3 digit POS entry mode.
+func ParsePOSEntryMode(value string) (POSEntryMode, error) {
+	if len(value) != 3 || !isNumeric(value) {
+		return POSEntryMode{}, fmt.Errorf("POS entry mode must have 3 digits, got %q", value)
+	}
+	return POSEntryMode{PANEntry: PANEntryMode(value[:2]), PINCapability: PINCapability(value[2:])}, nil
+}
+
+// String formats the POS entry mode as 3 digits.
+func (p POSEntryMode) String() string {
+	return string(p.PANEntry) + string(p.PINCapability)
+}
+
+// CardPresent reports whether the card was read at the terminal.
+func (p POSEntryMode) CardPresent() bool {
+	switch p.PANEntry {
+	case PANEntryMagstripe, PANEntryChip, PANEntryContactless, PANEntryChipFallback,
+		PANEntryMagstripeFullTrack, PANEntryContactlessMagstripe:
+		return true
+	}
+	return false
+}
+
+// CardDataInput is a card data input capability or mode of a 1993 POS data code.
+type CardDataInput byte
+
+// List of card data inputs.
+const (
+	InputUnknown     CardDataInput = '0'
+	InputManual      CardDataInput = '1'
+	InputMagstripe   CardDataInput = '2'
+	InputBarCode     CardDataInput = '3'
+	InputOCR         CardDataInput = '4'
+	InputChip        CardDataInput = '5'
+	InputKeyEntry    CardDataInput = '6'
+	InputContactless CardDataInput = 'M'
+
+	// InputContactlessMagstripe is a common network extension.
+	InputContactlessMagstripe CardDataInput = 'A'
+)
+
+// CardholderPresence tells whether and how the cardholder was present.
+type CardholderPresence byte
+
+// List of cardholder presence values.
+const (
+	CardholderPresent         CardholderPresence = '0'
+	CardholderNotPresent      CardholderPresence = '1'
+	CardholderMailOrder       CardholderPresence = '2'
+	CardholderTelephoneOrder  CardholderPresence = '3'
+	CardholderStandingOrder   CardholderPresence = '4'
+	CardholderElectronicOrder CardholderPresence = '5'
+)
+
+// CardPresence tells whether the card was present.
+type CardPresence byte
+
+// List of card presence values.
+const (
+	CardNotPresent CardPresence = '0'
+	CardPresent    CardPresence = '1'
+)
+
+// CardholderAuthMethod is how the cardholder was authenticated.
+type CardholderAuthMethod byte
+
+// List of cardholder authentication methods.
+const (
+	AuthNotAuthenticated    CardholderAuthMethod = '0'
+	AuthPIN                 CardholderAuthMethod = '1'
+	AuthElectronicSignature CardholderAuthMethod = '2'
+	AuthBiometric           CardholderAuthMethod = '3'
+	AuthBiographic          CardholderAuthMethod = '4'
+	AuthManualSignature     CardholderAuthMethod = '5'
+	AuthOtherManual         CardholderAuthMethod = '6'
+)
+
+// CardholderAuthCapability is how the terminal can authenticate the cardholder.
+type CardholderAuthCapability byte
+
+// List of cardholder authentication capabilities.
+const (
+	AuthCapabilityNone                CardholderAuthCapability = '0'
+	AuthCapabilityPIN                 CardholderAuthCapability = '1'
+	AuthCapabilityElectronicSignature CardholderAuthCapability = '2'
+	AuthCapabilityBiometric           CardholderAuthCapability = '3'
+	AuthCapabilityBiographic          CardholderAuthCapability = '4'
+	AuthCapabilityInoperative         CardholderAuthCapability = '5'
+	AuthCapabilityOther               CardholderAuthCapability = '6'
+)
+
+// CardCaptureCapability tells whether the terminal can capture cards.
+type CardCaptureCapability byte
+
+// List of card capture capabilities.
+const (
+	CaptureNone    CardCaptureCapability = '0'
+	CaptureCapable CardCaptureCapability = '1'
+)
+
+// OperatingEnvironment is where the terminal is and whether it is attended.
+type OperatingEnvironment byte
+
+// List of operating environments.
+const (
+	EnvironmentNoTerminal            OperatingEnvironment = '0'
+	EnvironmentAttended              OperatingEnvironment = '1'
+	EnvironmentUnattended            OperatingEnvironment = '2'
+	EnvironmentOffPremisesAttended   OperatingEnvironment = '3'
+	EnvironmentOffPremisesUnattended OperatingEnvironment = '4'
+	EnvironmentCardholderPremises    OperatingEnvironment = '5'
+)
+
+// CardholderAuthEntity is who authenticated the cardholder.
+type CardholderAuthEntity byte
+
+// List of cardholder authentication entities.
+const (
+	EntityNotAuthenticated CardholderAuthEntity = '0'
+	EntityChip             CardholderAuthEntity = '1'
+	EntityTerminal         CardholderAuthEntity = '2'
+	EntityAuthorizingAgent CardholderAuthEntity = '3'
+	EntityMerchant         CardholderAuthEntity = '4'
+	EntityOther            CardholderAuthEntity = '5'
+)
+
+// CardDataOutput is how the terminal can write card data.
+type CardDataOutput byte
+
+// List of card data output capabilities.
+const (
+	OutputUnknown   CardDataOutput = '0'
+	OutputNone      CardDataOutput = '1'
+	OutputMagstripe CardDataOutput = '2'
+	OutputChip      CardDataOutput = '3'
+)
+
+// TerminalOutputCapability is how the terminal can output information.
+type TerminalOutputCapability byte
+
+// List of terminal output capabilities.
+const (
+	TerminalOutputUnknown            TerminalOutputCapability = '0'
+	TerminalOutputNone               TerminalOutputCapability = '1'
+	TerminalOutputPrinting           TerminalOutputCapability = '2'
+	TerminalOutputDisplay            TerminalOutputCapability = '3'
+	TerminalOutputPrintingAndDisplay TerminalOutputCapability = '4'
+)
+
+// PINCaptureCapability tells whether the terminal can capture PINs and,
+// as '4' to '9' and 'A' to 'C', the maximum PIN length.
+type PINCaptureCapability byte
+
+// List of PIN capture capabilities without a length.
+const (
+	PINCaptureNone    PINCaptureCapability = '0'
+	PINCaptureUnknown PINCaptureCapability = '1'
+)
+
+// Length returns the maximum PIN length the terminal can capture, or 0
+// when it is not given.
+func (p PINCaptureCapability) Length() int {
+	switch {
+	case p >= '4' && p <= '9':
+		return int(p - '0')
+	case p >= 'A' && p <= 'C':
+		return int(p-'A') + 10
+	}
+	return 0
+}
+
+// POSDataCode is the 12 character POS data code of field 22 in ISO 8583:1993.
+type POSDataCode struct {
+	InputCapability      CardDataInput            // 1
+	AuthCapability       CardholderAuthCapability // 2
+	CaptureCapability    CardCaptureCapability    // 3
+	OperatingEnvironment OperatingEnvironment     // 4
+	CardholderPresence   CardholderPresence       // 5
+	CardPresence         CardPresence             // 6
+	InputMode            CardDataInput            // 7
+	AuthMethod           CardholderAuthMethod     // 8
+	AuthEntity           CardholderAuthEntity     // 9
+	OutputCapability     CardDataOutput           // 10
+	TerminalOutput       TerminalOutputCapability // 11
+	PINCaptureCapability PINCaptureCapability     // 12
+}
+
+// nationalUse holds the letters reserved for national and private use.
+const nationalUse = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// posDataCodePositions lists the name and valid characters of each
+// position of a POS data code.
+var posDataCodePositions = [12]struct {
+	name  string
+	valid string
+}{
+	{"card data input capability", "0123456" + nationalUse},
+	{"cardholder authentication capability", "0123456" + nationalUse},
+	{"card capture capability", "01"},
+	{"operating environment", "012345" + nationalUse},
+	{"cardholder presence", "012345" + nationalUse},
+	{"card presence", "01"},
+	{"card data input mode", "0123456" + nationalUse},
+	{"cardholder authentication method", "0123456" + nationalUse},
+	{"cardholder authentication entity", "012345" + nationalUse},
+	{"card data output capability", "0123" + nationalUse},
+	{"terminal output capability", "01234" + nationalUse},
+	{"PIN capture capability", "01456789ABC"},
+}
+
+// ParsePOSDataCode parses a 12 character POS data code.
+func ParsePOSDataCode(value string) (POSDataCode, error) {
+	if len(value) != 12 {
+		return POSDataCode{}, fmt.Errorf("POS data code must have 12 characters, got %q", value)
+	}
+
+	p := POSDataCode{
+		InputCapability:      CardDataInput(value[0]),
+		AuthCapability:       CardholderAuthCapability(value[1]),
+		CaptureCapability:    CardCaptureCapability(value[2]),
+		OperatingEnvironment: OperatingEnvironment(value[3]),
+		CardholderPresence:   CardholderPresence(value[4]),
+		CardPresence:         CardPresence(value[5]),
+		InputMode:            CardDataInput(value[6]),
+		AuthMethod:           CardholderAuthMethod(value[7]),
+		AuthEntity:           CardholderAuthEntity(value[8]),
+		OutputCapability:     CardDataOutput(value[9]),
+		TerminalOutput:       TerminalOutputCapability(value[10]),
+		PINCaptureCapability: PINCaptureCapability(value[11]),
+	}
+
+	return p, p.Validate()
+}
+
+// String formats the POS data code as 12 characters.
+func (p POSDataCode) String() string {
+	return string([]byte{
+		byte(p.InputCapability), byte(p.AuthCapability), byte(p.CaptureCapability), byte(p.OperatingEnvironment),
+		byte(p.CardholderPresence), byte(p.CardPresence), byte(p.InputMode), byte(p.AuthMethod),
+		byte(p.AuthEntity), byte(p.OutputCapability), byte(p.TerminalOutput), byte(p.PINCaptureCapability),
+	})
+}
+
+// Validate checks that each position holds a value defined for it.
+func (p POSDataCode) Validate() error {
+	for i, c := range []byte(p.String()) {
+		position := posDataCodePositions[i]
+		if !strings.ContainsRune(position.valid, rune(c)) {
+			return fmt.Errorf("invalid %s %q in POS data code position %d", position.name, c, i+1)
+		}
+	}
+	return nil
+}
+
+// Ecommerce reports whether the transaction is an electronic order
+// without the card present.
+func (p POSDataCode) Ecommerce() bool {
+	return p.CardholderPresence == CardholderElectronicOrder && p.CardPresence == CardNotPresent
+}
+
+// POSConditionCode is the 2 digit POS condition code of field 25.
+type POSConditionCode string
+
+// List of common POS condition codes.
+const (
+	ConditionNormal              POSConditionCode = "00"
+	ConditionCustomerNotPresent  POSConditionCode = "01"
+	ConditionUnattendedTerminal  POSConditionCode = "02"
+	ConditionMerchantSuspicious  POSConditionCode = "03"
+	ConditionCardNotPresent      POSConditionCode = "05"
+	ConditionPreauthorization    POSConditionCode = "06"
+	ConditionMailTelephoneOrder  POSConditionCode = "08"
+	ConditionEcommerce           POSConditionCode = "59"
+	ConditionMagstripeUnreadable POSConditionCode = "71"
+)
+
+var posConditionNames = map[POSConditionCode]string{
+	ConditionNormal:              "normal presentment",
+	ConditionCustomerNotPresent:  "customer not present",
+	ConditionUnattendedTerminal:  "unattended terminal",
+	ConditionMerchantSuspicious:  "merchant suspicious",
+	ConditionCardNotPresent:      "customer present, card not present",
+	ConditionPreauthorization:    "preauthorization request",
+	ConditionMailTelephoneOrder:  "mail/telephone order",
+	ConditionEcommerce:           "e-commerce",
+	ConditionMagstripeUnreadable: "magnetic stripe unreadable",
+}
+
+// String returns the name of the POS condition code.
+func (c POSConditionCode) String() string {
+	if name, ok := posConditionNames[c]; ok {
+		return name
+	}
+	return "POS condition " + string(c)
+}
+
+// POSEntryMode returns field 22 of the message as a 1987 POS entry mode.
+func (m *Parser) POSEntryMode() (POSEntryMode, error) {
+	return ParsePOSEntryMode(m.Fields[22])
+}
+
+// POSDataCode returns field 22 of the message as a 1993 POS data code.
+func (m *Parser) POSDataCode() (POSDataCode, error) {
+	return ParsePOSDataCode(m.Fields[22])
+}
+
+// POSConditionCode returns field 25 of the message. It fails for specs
+// of ISO 8583:1993 and later, where field 25 is the message reason code.
+func (m *Parser) POSConditionCode() (POSConditionCode, error) {
+	if edition := m.Spec().edition(); edition >= 1993 {
+		return "", fmt.Errorf("field 25 is the message reason code in ISO 8583:%d", edition)
+	}
+
+	value := m.Fields[25]
+	if len(value) != 2 || !isNumeric(value) {
+		return "", fmt.Errorf("POS condition code must have 2 digits, got %q", value)
+	}
+	return POSConditionCode(value), nil
+}
+
+// MessageReasonCode returns field 25 of the message, the 4 digit message
+// reason code of ISO 8583:1993 and later. It fails for earlier specs,
+// where field 25 is the POS condition code.
+func (m *Parser) MessageReasonCode() (string, error) {
+	if edition := m.Spec().edition(); edition < 1993 {
+		return "", fmt.Errorf("field 25 is the POS condition code in ISO 8583:%d", edition)
+	}
+
+	value := m.Fields[25]
+	if len(value) != 4 || !isNumeric(value) {
+		return "", fmt.Errorf("message reason code must have 4 digits, got %q", value)
+	}
+	return value, nil
+}
+
+// SetPOSEntryMode sets field 22 from a 1987 POS entry mode.
+func (mb *MessageBuilder) SetPOSEntryMode(p POSEntryMode) *MessageBuilder {
+	return mb.AddField(22, p.String())
+}
+
+// SetPOSDataCode sets field 22 from a 1993 POS data code. The builder's
+// spec must define field 22 wide enough for it, as SpecWithPOSDataCode
+// does.
+func (mb *MessageBuilder) SetPOSDataCode(p POSDataCode) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if elem, ok := mb.Spec().Element(22); !ok || elem.MaxLen < 12 {
+		return fmt.Errorf("field 22 of spec %s cannot hold a 12 character POS data code", mb.Spec().Name)
+	}
+	mb.AddField(22, p.String())
+	return nil
+}
+
+// SetPOSConditionCode sets field 25.
+func (mb *MessageBuilder) SetPOSConditionCode(c POSConditionCode) *MessageBuilder {
+	return mb.AddField(25, string(c))
+}
@@ -0,0 +1,181 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestPOSEntryMode(t *testing.T) {
+	mode, err := ParsePOSEntryMode("051")
+	if err != nil {
+		t.Fatalf("ParsePOSEntryMode() error = %v", err)
+	}
+
+	if mode.PANEntry != PANEntryChip || mode.PINCapability != PINCapable {
+		t.Errorf("Unexpected POS entry mode %+v", mode)
+	}
+	if !mode.CardPresent() {
+		t.Errorf("Expected chip to be card present")
+	}
+	if mode.PANEntry.String() != "chip" || PANEntryMode("42").String() != "PAN entry mode 42" {
+		t.Errorf("Unexpected names %s, %s", mode.PANEntry, PANEntryMode("42"))
+	}
+
+	if manual := (POSEntryMode{PANEntry: PANEntryManual, PINCapability: PINNotCapable}); manual.String() != "012" || manual.CardPresent() {
+		t.Errorf("Unexpected manual POS entry mode %s", manual)
+	}
+
+	for _, invalid := range []string{"05", "0511", "0A1"} {
+		if _, err := ParsePOSEntryMode(invalid); err == nil {
+			t.Errorf("ParsePOSEntryMode(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestPOSDataCode(t *testing.T) {
+	code, err := ParsePOSDataCode("510101513344")
+	if err != nil {
+		t.Fatalf("ParsePOSDataCode() error = %v", err)
+	}
+
+	if code.InputCapability != InputChip || code.InputMode != InputChip || code.AuthMethod != AuthPIN {
+		t.Errorf("Unexpected POS data code %+v", code)
+	}
+	if code.CardholderPresence != CardholderPresent || code.CardPresence != CardPresent {
+		t.Errorf("Expected cardholder and card present, got %+v", code)
+	}
+	if code.AuthCapability != AuthCapabilityPIN || code.OperatingEnvironment != EnvironmentAttended || code.AuthEntity != EntityAuthorizingAgent {
+		t.Errorf("Unexpected terminal and authentication values %+v", code)
+	}
+	if code.TerminalOutput != TerminalOutputPrintingAndDisplay || code.PINCaptureCapability.Length() != 4 {
+		t.Errorf("Unexpected output and PIN capture values %+v", code)
+	}
+	if PINCaptureCapability('C').Length() != 12 || PINCaptureNone.Length() != 0 {
+		t.Errorf("Unexpected PIN capture lengths")
+	}
+	if code.String() != "510101513344" {
+		t.Errorf("Unexpected formatting %s", code)
+	}
+
+	code.CardholderPresence = CardholderElectronicOrder
+	code.CardPresence = CardNotPresent
+	code.InputMode = InputKeyEntry
+	if !code.Ecommerce() {
+		t.Errorf("Expected electronic order without card to be e-commerce")
+	}
+
+	for _, invalid := range []string{"051", "5!0101513344", "512101513344", "510101513342", "510101513 44"} {
+		if _, err := ParsePOSDataCode(invalid); err == nil {
+			t.Errorf("ParsePOSDataCode(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestSetPOSDataCode(t *testing.T) {
+	code := POSDataCode{
+		InputCapability:      InputContactless,
+		AuthCapability:       AuthCapabilityPIN,
+		CaptureCapability:    CaptureNone,
+		OperatingEnvironment: EnvironmentAttended,
+		CardholderPresence:   CardholderPresent,
+		CardPresence:         CardPresent,
+		InputMode:            InputContactless,
+		AuthMethod:           AuthPIN,
+		AuthEntity:           EntityAuthorizingAgent,
+		OutputCapability:     OutputNone,
+		TerminalOutput:       TerminalOutputDisplay,
+		PINCaptureCapability: PINCaptureCapability('6'),
+	}
+
+	if err := NewISO().SetPOSDataCode(code); err == nil {
+		t.Errorf("Expected error setting a POS data code with a 3 digit field 22")
+	}
+
+	msg := NewISOWithSpec(SpecWithPOSDataCode)
+	msg.SetMTI("1200")
+	if err := msg.SetPOSDataCode(code); err != nil {
+		t.Fatalf("SetPOSDataCode() error = %v", err)
+	}
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParserWithSpec(SpecWithPOSDataCode).Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	decoded, err := parsed.POSDataCode()
+	if err != nil {
+		t.Fatalf("POSDataCode() error = %v", err)
+	}
+	if decoded != code {
+		t.Errorf("Expected %s, got %s", code, decoded)
+	}
+
+	code.CaptureCapability = '7'
+	if err := msg.SetPOSDataCode(code); err == nil {
+		t.Errorf("Expected error for invalid card capture capability")
+	}
+}
+
+func TestPOSFields(t *testing.T) {
+	msg := NewISO()
+	msg.SetMTI("0200")
+	msg.SetPOSEntryMode(POSEntryMode{PANEntry: PANEntryContactless, PINCapability: PINCapable})
+	msg.SetPOSConditionCode(ConditionNormal)
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	mode, err := parsed.POSEntryMode()
+	if err != nil || mode.PANEntry != PANEntryContactless {
+		t.Errorf("Expected contactless POS entry mode, got %+v (error = %v)", mode, err)
+	}
+
+	condition, err := parsed.POSConditionCode()
+	if err != nil || condition != ConditionNormal || condition.String() != "normal presentment" {
+		t.Errorf("Expected normal POS condition, got %s (error = %v)", condition, err)
+	}
+}
+
+func TestField25ByEdition(t *testing.T) {
+	spec1993 := DefaultSpec.Clone()
+	spec1993.Version = 1993
+	elem := spec1993.Elements[25]
+	elem.MaxLen = 4
+	spec1993.Elements[25] = elem
+
+	raw, err := NewISOWithSpec(spec1993).SetMTI("1200").AddField(25, "1508").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParserWithSpec(spec1993).Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if reason, err := parsed.MessageReasonCode(); err != nil || reason != "1508" {
+		t.Errorf("Expected message reason code 1508, got %s (error = %v)", reason, err)
+	}
+	if _, err := parsed.POSConditionCode(); err == nil {
+		t.Errorf("Expected error reading a POS condition code from a 1993 message")
+	}
+
+	parsed, err = NewParser().Parse("0200" + "0000008000000000" + "00")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := parsed.MessageReasonCode(); err == nil {
+		t.Errorf("Expected error reading a message reason code from a 1987 message")
+	}
+}
@@ -0,0 +1,137 @@
+package iso8583
+
+import (
+	"fmt"
+)
+
+// TransactionType is the 2 digit transaction type of a processing code.
+type TransactionType string
+
+// List of common transaction types.
+const (
+	TransactionPurchase         TransactionType = "00"
+	TransactionCash             TransactionType = "01"
+	TransactionPurchaseCashBack TransactionType = "09"
+	TransactionRefund           TransactionType = "20"
+	TransactionBalanceInquiry   TransactionType = "30"
+	TransactionTransfer         TransactionType = "40"
+	TransactionPayment          TransactionType = "50"
+)
+
+// ProcessingCodeCatalog lists the transaction and account types accepted
+// in processing codes, with their names.
+type ProcessingCodeCatalog struct {
+	Transactions map[TransactionType]string
+	Accounts     map[AccountType]string
+}
+
+// DefaultProcessingCodes is the catalog of the named transaction and
+// account types.
+var DefaultProcessingCodes = &ProcessingCodeCatalog{
+	Transactions: map[TransactionType]string{
+		TransactionPurchase:         "purchase",
+		TransactionCash:             "cash",
+		TransactionPurchaseCashBack: "purchase with cash back",
+		TransactionRefund:           "refund",
+		TransactionBalanceInquiry:   "balance inquiry",
+		TransactionTransfer:         "transfer",
+		TransactionPayment:          "payment",
+	},
+	Accounts: map[AccountType]string{
+		AccountDefault:  "default",
+		AccountSavings:  "savings",
+		AccountChecking: "checking",
+		AccountCredit:   "credit",
+	},
+}
+
+// Validate rejects processing codes with transaction or account types
+// missing from the catalog.
+func (c *ProcessingCodeCatalog) Validate(p ProcessingCode) error {
+	if _, ok := c.Transactions[p.Transaction]; !ok {
+		return fmt.Errorf("unknown transaction type %s", string(p.Transaction))
+	}
+	for _, account := range []AccountType{p.From, p.To} {
+		if _, ok := c.Accounts[account]; !ok {
+			return fmt.Errorf("unknown account type %s", string(account))
+		}
+	}
+	return nil
+}
+
+// String returns the name of the transaction type.
+func (t TransactionType) String() string {
+	if name, ok := DefaultProcessingCodes.Transactions[t]; ok {
+		return name
+	}
+	return "transaction type " + string(t)
+}
+
+// String returns the name of the account type.
+func (a AccountType) String() string {
+	if name, ok := DefaultProcessingCodes.Accounts[a]; ok {
+		return name
+	}
+	return "account type " + string(a)
+}
+
+// ProcessingCode is the content of field 3: the transaction type and the
+// account types money is moved from and to.
+type ProcessingCode struct {
+	Transaction TransactionType
+	From        AccountType
+	To          AccountType
+}
+
+// ParseProcessingCode parses a 6 digit processing code.
+func ParseProcessingCode(value string) (ProcessingCode, error) {
+	if len(value) != 6 || !isNumeric(value) {
+		return ProcessingCode{}, fmt.Errorf("processing code must have 6 digits, got %q", value)
+	}
+
+	return ProcessingCode{
+		Transaction: TransactionType(value[0:2]),
+		From:        AccountType(value[2:4]),
+		To:          AccountType(value[4:6]),
+	}, nil
+}
+
+// String formats the processing code as 6 digits.
+func (p ProcessingCode) String() string {
+	return string(p.Transaction) + string(p.From) + string(p.To)
+}
+
+// ProcessingCode returns field 3 of the message, validated against the
+// spec's processing code catalog when one is configured.
+func (m *Parser) ProcessingCode() (ProcessingCode, error) {
+	p, err := ParseProcessingCode(m.Fields[3])
+	if err != nil {
+		return ProcessingCode{}, err
+	}
+
+	if catalog := m.Spec().ProcessingCodes; catalog != nil {
+		if err := catalog.Validate(p); err != nil {
+			return ProcessingCode{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// SetProcessingCode sets field 3, validating it against the spec's
+// processing code catalog when one is configured.
+func (mb *MessageBuilder) SetProcessingCode(p ProcessingCode) error {
+	if _, err := ParseProcessingCode(p.String()); err != nil {
+		return err
+	}
+
+	if catalog := mb.Spec().ProcessingCodes; catalog != nil {
+		if err := catalog.Validate(p); err != nil {
+			return err
+		}
+	}
+
+	mb.AddField(3, p.String())
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestParseProcessingCode(t *testing.T) {
+	p, err := ParseProcessingCode("402010")
+	if err != nil {
+		t.Fatalf("ParseProcessingCode() error = %v", err)
+	}
+
+	expected := ProcessingCode{Transaction: TransactionTransfer, From: AccountChecking, To: AccountSavings}
+	if p != expected {
+		t.Errorf("Expected %+v, got %+v", expected, p)
+	}
+	if p.String() != "402010" {
+		t.Errorf("Unexpected formatting %s", p)
+	}
+	if p.Transaction.String() != "transfer" || p.From.String() != "checking" {
+		t.Errorf("Unexpected names %s, %s", p.Transaction, p.From)
+	}
+
+	for _, invalid := range []string{"00000", "0000000", "00A000"} {
+		if _, err := ParseProcessingCode(invalid); err == nil {
+			t.Errorf("ParseProcessingCode(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestProcessingCodeCatalog(t *testing.T) {
+	lenient := NewParser()
+	lenient.Fields[3] = "990000"
+	if _, err := lenient.ProcessingCode(); err != nil {
+		t.Errorf("Expected unknown codes to be accepted without a catalog, got %v", err)
+	}
+
+	spec := DefaultSpec.Clone()
+	spec.ProcessingCodes = DefaultProcessingCodes
+
+	strict := NewParserWithSpec(spec)
+	strict.Fields[3] = "990000"
+	if _, err := strict.ProcessingCode(); err == nil {
+		t.Errorf("Expected unknown transaction type to be rejected")
+	}
+
+	strict.Fields[3] = "007000"
+	if _, err := strict.ProcessingCode(); err == nil {
+		t.Errorf("Expected unknown account type to be rejected")
+	}
+
+	msg := NewISOWithSpec(spec)
+	if err := msg.SetProcessingCode(ProcessingCode{Transaction: TransactionRefund, From: AccountDefault, To: AccountDefault}); err != nil {
+		t.Errorf("SetProcessingCode() error = %v", err)
+	}
+	if msg.Fields[3] != "200000" {
+		t.Errorf("Field 3: expected 200000, got %s", msg.Fields[3])
+	}
+	if err := msg.SetProcessingCode(ProcessingCode{Transaction: "99", From: AccountDefault, To: AccountDefault}); err == nil {
+		t.Errorf("Expected error setting an unknown transaction type")
+	}
+}
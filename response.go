@@ -0,0 +1,49 @@
+package iso8583
+
+import (
+	"fmt"
+)
+
+// ResponseMTI returns the response MTI for a request MTI by adding 10
+// to it, e.g. 0100 becomes 0110 and 0420 becomes 0430.
+func ResponseMTI(mti string) (string, error) {
+	if len(mti) != 4 {
+		return "", fmt.Errorf("invalid MTI %q", mti)
+	}
+
+	function := mti[2]
+	if function < '0' || function > '9' {
+		return "", fmt.Errorf("invalid MTI %q", mti)
+	}
+
+	// Odd message functions are already responses or acknowledgements
+	if (function-'0')%2 != 0 {
+		return "", fmt.Errorf("MTI %s is not a request", mti)
+	}
+
+	return mti[:2] + string(function+1) + mti[3:], nil
+}
+
+// NewResponse creates a response for a parsed request. The response
+// MTI is derived from the request MTI and the fields listed in the
+// spec's EchoFields are copied from the request, leaving the caller
+// to set the authorization code (38) and response code (39).
+func NewResponse(request *Parser) (*MessageBuilder, error) {
+	mti, err := ResponseMTI(request.MTI)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := request.Spec()
+
+	response := NewISOWithSpec(spec)
+	response.SetMTI(mti)
+
+	for _, fieldNum := range spec.EchoFields {
+		if value, ok := request.Fields[fieldNum]; ok {
+			response.AddField(fieldNum, value)
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,89 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestResponseMTI(t *testing.T) {
+	tests := map[string]string{
+		"0100": "0110",
+		"0200": "0210",
+		"0420": "0430",
+		"0800": "0810",
+	}
+
+	for request, expected := range tests {
+		mti, err := ResponseMTI(request)
+		if err != nil {
+			t.Errorf("ResponseMTI(%s) error = %v", request, err)
+			continue
+		}
+		if mti != expected {
+			t.Errorf("ResponseMTI(%s): expected %s, got %s", request, expected, mti)
+		}
+	}
+
+	for _, invalid := range []string{"0110", "02", "02X0"} {
+		if _, err := ResponseMTI(invalid); err == nil {
+			t.Errorf("ResponseMTI(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestNewResponse(t *testing.T) {
+	request := NewISO()
+	request.SetMTI("0200")
+	request.AddField(2, "4000001234567890")
+	request.AddField(3, "000000")
+	request.AddField(4, "000000006000")
+	request.AddField(11, "000001")
+	request.AddField(41, "TERM1234")
+	request.AddField(52, "12345678")
+
+	raw, err := request.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	response, err := NewResponse(parsed)
+	if err != nil {
+		t.Fatalf("NewResponse() error = %v", err)
+	}
+
+	if response.MTI != "0210" {
+		t.Errorf("Expected MTI = 0210, got %s", response.MTI)
+	}
+
+	for _, fieldNum := range []int{2, 3, 4, 11, 41} {
+		if response.Fields[fieldNum] != parsed.Fields[fieldNum] {
+			t.Errorf("Field %d: expected %s, got %s", fieldNum, parsed.Fields[fieldNum], response.Fields[fieldNum])
+		}
+	}
+
+	if _, ok := response.Fields[52]; ok {
+		t.Errorf("Field 52: expected not to be echoed")
+	}
+}
+
+func TestNewResponseCustomEchoFields(t *testing.T) {
+	spec := &Spec{Elements: DefaultSpec.Elements, EchoFields: []int{11}}
+
+	request := NewParserWithSpec(spec)
+	request.MTI = "0100"
+	request.Fields[3] = "000000"
+	request.Fields[11] = "000042"
+
+	response, err := NewResponse(request)
+	if err != nil {
+		t.Fatalf("NewResponse() error = %v", err)
+	}
+
+	if len(response.Fields) != 1 || response.Fields[11] != "000042" {
+		t.Errorf("Expected only field 11 to be echoed, got %v", response.Fields)
+	}
+}
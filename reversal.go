@@ -0,0 +1,183 @@
+package iso8583
+
+import (
+	"fmt"
+)
+
+// ReplacementAmounts holds the actual amounts of a partially completed
+// transaction carried in field 95 of a partial reversal.
+type ReplacementAmounts struct {
+	Transaction    string // n 12
+	Settlement     string // n 12
+	TransactionFee string // x+n 9
+	SettlementFee  string // x+n 9
+}
+
+// String formats the replacement amounts as the 42 character field 95 value.
+func (r ReplacementAmounts) String() string {
+	return padOrTruncate(r.Transaction, 12, "n") +
+		padOrTruncate(r.Settlement, 12, "n") +
+		padOrTruncate(r.TransactionFee, 9, "x+n") +
+		padOrTruncate(r.SettlementFee, 9, "x+n")
+}
+
+// Validate checks that the amounts are numeric and the fees carry a C/D sign.
+func (r ReplacementAmounts) Validate() error {
+	if !isNumeric(r.Transaction) || !isNumeric(r.Settlement) {
+		return fmt.Errorf("replacement amounts must be numeric")
+	}
+
+	for _, fee := range []string{r.TransactionFee, r.SettlementFee} {
+		if fee == "" {
+			continue
+		}
+		if _, err := ParseSignedAmount(fee); err != nil {
+			return fmt.Errorf("invalid replacement fee amount: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// NewReversal creates a reversal request (0400) for an original request.
+func NewReversal(original *MessageBuilder) (*MessageBuilder, error) {
+	return newReversal(original, "00")
+}
+
+// NewReversalAdvice creates a reversal advice (0420) for an original request.
+func NewReversalAdvice(original *MessageBuilder) (*MessageBuilder, error) {
+	return newReversal(original, "20")
+}
+
+// NewReversalFromRequest creates a reversal request (0400) for an
+// original request received and parsed, e.g. by a host or gateway.
+func NewReversalFromRequest(original *Parser) (*MessageBuilder, error) {
+	return newReversal(builderOf(original), "00")
+}
+
+// NewReversalAdviceFromRequest creates a reversal advice (0420) for an
+// original request received and parsed.
+func NewReversalAdviceFromRequest(original *Parser) (*MessageBuilder, error) {
+	return newReversal(builderOf(original), "20")
+}
+
+// NewPartialReversal creates a reversal request (0400) for an original
+// request that was only partially completed, carrying the actual
+// amounts in field 95.
+func NewPartialReversal(original *MessageBuilder, actual ReplacementAmounts) (*MessageBuilder, error) {
+	if err := actual.Validate(); err != nil {
+		return nil, err
+	}
+
+	reversal, err := NewReversal(original)
+	if err != nil {
+		return nil, err
+	}
+
+	reversal.AddField(95, actual.String())
+
+	return reversal, nil
+}
+
+// NewAdvice creates an advice for an original request, e.g. a 0120 for
+// a 0100 or a 0220 for a 0200. The original fields are copied except
+// for the PIN block and MACs, and field 90 refers to the original in
+// place of its own field 90.
+func NewAdvice(original *MessageBuilder) (*MessageBuilder, error) {
+	if len(original.MTI) != 4 || original.MTI[2] != '0' {
+		return nil, fmt.Errorf("MTI %q is not a request", original.MTI)
+	}
+
+	ode, err := OriginalDataElementsOf(original.MTI, original.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	advice := NewISOWithSpec(original.Spec())
+	advice.SetMTI(original.MTI[:2] + "2" + original.MTI[3:])
+
+	present := make(map[int]bool)
+	for fieldNum := range original.Fields {
+		present[fieldNum] = true
+	}
+	for path := range original.Subfields {
+		if fieldNum, err := subfieldNumber(path); err == nil {
+			present[fieldNum] = true
+		}
+	}
+
+	for fieldNum := range present {
+		switch fieldNum {
+		case 52, 64, 90, 128:
+			continue
+		}
+		copyField(advice, original, fieldNum)
+	}
+
+	advice.AddField(90, ode.String())
+
+	return advice, nil
+}
+
+// newReversal creates a reversal with the given message function and
+// origin digits, copying the spec's ReversalFields from the original.
+func newReversal(original *MessageBuilder, function string) (*MessageBuilder, error) {
+	if len(original.MTI) != 4 || original.MTI[2] != '0' {
+		return nil, fmt.Errorf("MTI %q is not a request", original.MTI)
+	}
+
+	ode, err := OriginalDataElementsOf(original.MTI, original.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := original.Spec()
+
+	reversal := NewISOWithSpec(spec)
+	reversal.SetMTI(original.MTI[:1] + "4" + function)
+
+	for _, fieldNum := range spec.ReversalFields {
+		if fieldNum != 90 {
+			copyField(reversal, original, fieldNum)
+		}
+	}
+
+	reversal.AddField(90, ode.String())
+
+	return reversal, nil
+}
+
+// copyField copies a field from one builder to another, including the
+// subfields it is composed from.
+func copyField(dst, src *MessageBuilder, fieldNum int) {
+	if value, ok := src.Fields[fieldNum]; ok {
+		dst.AddField(fieldNum, value)
+	}
+	for path, value := range src.Subfields {
+		if n, err := subfieldNumber(path); err == nil && n == fieldNum {
+			dst.SetSubfield(path, value)
+		}
+	}
+}
+
+// builderOf returns a builder holding the fields of a parsed message.
+// Composite fields keep their full value, so their subfields are not
+// copied.
+func builderOf(m *Parser) *MessageBuilder {
+	mb := NewISOWithSpec(m.Spec())
+	mb.SetMTI(m.MTI)
+	for fieldNum, value := range m.Fields {
+		mb.AddField(fieldNum, value)
+	}
+	return mb
+}
+
+// isNumeric reports whether s contains only decimal digits.
+func isNumeric(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func newOriginalRequest() *MessageBuilder {
+	original := NewISO()
+	original.SetMTI("0200")
+	original.AddField(2, "4000001234567890")
+	original.AddField(3, "000000")
+	original.AddField(4, "000000006000")
+	original.AddField(7, "0209123456")
+	original.AddField(11, "000123")
+	original.AddField(32, "123456")
+	original.AddField(41, "TERM1234")
+	original.AddField(52, "12345678")
+	return original
+}
+
+func TestNewReversal(t *testing.T) {
+	reversal, err := NewReversal(newOriginalRequest())
+	if err != nil {
+		t.Fatalf("NewReversal() error = %v", err)
+	}
+
+	if reversal.MTI != "0400" {
+		t.Errorf("Expected MTI = 0400, got %s", reversal.MTI)
+	}
+
+	expected := "0200" + "000123" + "0209123456" + "00000123456" + "00000000000"
+	if reversal.Fields[90] != expected {
+		t.Errorf("Field 90: expected %s, got %s", expected, reversal.Fields[90])
+	}
+
+	if reversal.Fields[4] != "000000006000" {
+		t.Errorf("Field 4: expected original amount, got %s", reversal.Fields[4])
+	}
+
+	if _, ok := reversal.Fields[52]; ok {
+		t.Errorf("Field 52: expected not to be copied")
+	}
+
+	if _, err := reversal.Build(); err != nil {
+		t.Errorf("Build() error = %v", err)
+	}
+}
+
+func TestNewReversalAdvice(t *testing.T) {
+	reversal, err := NewReversalAdvice(newOriginalRequest())
+	if err != nil {
+		t.Fatalf("NewReversalAdvice() error = %v", err)
+	}
+
+	if reversal.MTI != "0420" {
+		t.Errorf("Expected MTI = 0420, got %s", reversal.MTI)
+	}
+}
+
+func TestNewPartialReversal(t *testing.T) {
+	reversal, err := NewPartialReversal(newOriginalRequest(), ReplacementAmounts{Transaction: "2500"})
+	if err != nil {
+		t.Fatalf("NewPartialReversal() error = %v", err)
+	}
+
+	expected := "000000002500" + "000000000000" + "C00000000" + "C00000000"
+	if reversal.Fields[95] != expected {
+		t.Errorf("Field 95: expected %s, got %s", expected, reversal.Fields[95])
+	}
+
+	if _, err := NewPartialReversal(newOriginalRequest(), ReplacementAmounts{TransactionFee: "X1"}); err == nil {
+		t.Errorf("Expected error for invalid fee sign")
+	}
+}
+
+func TestNewAdvice(t *testing.T) {
+	advice, err := NewAdvice(newOriginalRequest())
+	if err != nil {
+		t.Fatalf("NewAdvice() error = %v", err)
+	}
+
+	if advice.MTI != "0220" {
+		t.Errorf("Expected MTI = 0220, got %s", advice.MTI)
+	}
+
+	if _, ok := advice.Fields[52]; ok {
+		t.Errorf("Field 52: expected not to be copied")
+	}
+
+	if advice.Fields[90][:4] != "0200" {
+		t.Errorf("Field 90: expected original MTI, got %s", advice.Fields[90])
+	}
+
+	response := NewISO().SetMTI("0210")
+	if _, err := NewAdvice(response); err == nil {
+		t.Errorf("Expected error deriving an advice from a response")
+	}
+}
+
+func TestNewReversalCopiesSubfields(t *testing.T) {
+	original := newOriginalRequest()
+	original.SetSubfield("43.1", "ACME STORE")
+	original.SetSubfield("43.2", "SAO PAULO")
+	original.SetSubfield("43.3", "BR")
+
+	reversal, err := NewReversal(original)
+	if err != nil {
+		t.Fatalf("NewReversal() error = %v", err)
+	}
+
+	raw, err := reversal.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if city, _ := parsed.Subfield("43.2"); city != "SAO PAULO    " {
+		t.Errorf("Subfield 43.2: expected SAO PAULO, got %q", city)
+	}
+}
+
+func TestNewReversalFromRequest(t *testing.T) {
+	original := newOriginalRequest()
+	original.SetSubfield("43.1", "ACME STORE")
+
+	raw, err := original.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	request, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	reversal, err := NewReversalFromRequest(request)
+	if err != nil {
+		t.Fatalf("NewReversalFromRequest() error = %v", err)
+	}
+
+	if reversal.MTI != "0400" {
+		t.Errorf("Expected MTI = 0400, got %s", reversal.MTI)
+	}
+	expected := "0200" + "000123" + "0209123456" + "00000123456" + "00000000000"
+	if reversal.Fields[90] != expected {
+		t.Errorf("Field 90: expected %s, got %s", expected, reversal.Fields[90])
+	}
+	if reversal.Fields[43] != request.Fields[43] {
+		t.Errorf("Field 43: expected %q, got %q", request.Fields[43], reversal.Fields[43])
+	}
+	if _, ok := reversal.Fields[52]; ok {
+		t.Errorf("Field 52 should not be copied to the reversal")
+	}
+
+	advice, err := NewReversalAdviceFromRequest(request)
+	if err != nil {
+		t.Fatalf("NewReversalAdviceFromRequest() error = %v", err)
+	}
+	if advice.MTI != "0420" {
+		t.Errorf("Expected MTI = 0420, got %s", advice.MTI)
+	}
+}
+
+func TestNewAdviceCopiesSubfields(t *testing.T) {
+	original := newOriginalRequest()
+	original.SetSubfield("43.1", "ACME STORE")
+
+	advice, err := NewAdvice(original)
+	if err != nil {
+		t.Fatalf("NewAdvice() error = %v", err)
+	}
+
+	if value, ok := advice.Subfield("43.1"); !ok || value != "ACME STORE" {
+		t.Errorf("Subfield 43.1: expected ACME STORE, got %q", value)
+	}
+	if _, ok := advice.Fields[52]; ok {
+		t.Errorf("Field 52 should not be copied to the advice")
+	}
+}
+
+func TestNewAdviceReplacesOriginalField90Subfields(t *testing.T) {
+	original := newOriginalRequest()
+	original.SetSubfield("90.1", "0100")
+	original.SetSubfield("90.2", "999999")
+
+	advice, err := NewAdvice(original)
+	if err != nil {
+		t.Fatalf("NewAdvice() error = %v", err)
+	}
+
+	raw, err := advice.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if parsed.Fields[90] != advice.Fields[90] {
+		t.Errorf("Field 90: expected %s, got %s", advice.Fields[90], parsed.Fields[90])
+	}
+	if mti, _ := parsed.Subfield("90.1"); mti != "0200" {
+		t.Errorf("Subfield 90.1: expected original MTI 0200, got %s", mti)
+	}
+}
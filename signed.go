@@ -0,0 +1,78 @@
+package iso8583
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ParseSignedAmount parses an x+n amount, a C (credit) or D (debit) sign
+// followed by digits, returning it in minor units, negative for debits.
+func ParseSignedAmount(value string) (int64, error) {
+	if len(value) < 2 || !isNumeric(value[1:]) {
+		return 0, fmt.Errorf("invalid x+n amount %q", value)
+	}
+
+	amount, err := strconv.ParseInt(value[1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid x+n amount %q: %v", value, err)
+	}
+
+	switch value[0] {
+	case 'C':
+		return amount, nil
+	case 'D':
+		return -amount, nil
+	default:
+		return 0, fmt.Errorf("invalid x+n amount sign %q", value[0])
+	}
+}
+
+// FormatSignedAmount formats an amount in minor units as an x+n value of
+// the given total length, with a D sign for negative amounts.
+func FormatSignedAmount(amount int64, length int) (string, error) {
+	sign := "C"
+	if amount < 0 {
+		sign, amount = "D", -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if len(digits) > length-1 {
+		return "", fmt.Errorf("amount %d does not fit in %d digits", amount, length-1)
+	}
+
+	return sign + fmt.Sprintf("%0*s", length-1, digits), nil
+}
+
+// SetSignedAmount sets an x+n field, such as a fee (28-31) or the net
+// settlement amount (97), from an amount in minor units.
+func (mb *MessageBuilder) SetSignedAmount(fieldNum int, amount int64) error {
+	elem, ok := mb.Spec().Element(fieldNum)
+	if !ok || elem.ContentType != "x+n" {
+		return fmt.Errorf("field %d is not an x+n field", fieldNum)
+	}
+
+	value, err := FormatSignedAmount(amount, elem.MaxLen)
+	if err != nil {
+		return fmt.Errorf("field %d: %v", fieldNum, err)
+	}
+
+	mb.AddField(fieldNum, value)
+
+	return nil
+}
+
+// SignedAmount returns an x+n field of the message in minor units,
+// negative for debits.
+func (m *Parser) SignedAmount(fieldNum int) (int64, error) {
+	value, ok := m.Fields[fieldNum]
+	if !ok {
+		return 0, fmt.Errorf("message has no field %d", fieldNum)
+	}
+
+	amount, err := ParseSignedAmount(value)
+	if err != nil {
+		return 0, fmt.Errorf("field %d: %v", fieldNum, err)
+	}
+
+	return amount, nil
+}
@@ -0,0 +1,81 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestParseSignedAmount(t *testing.T) {
+	tests := map[string]int64{
+		"C00000150": 150,
+		"D00000150": -150,
+		"C0":        0,
+	}
+
+	for value, expected := range tests {
+		amount, err := ParseSignedAmount(value)
+		if err != nil {
+			t.Errorf("ParseSignedAmount(%s) error = %v", value, err)
+			continue
+		}
+		if amount != expected {
+			t.Errorf("ParseSignedAmount(%s): expected %d, got %d", value, expected, amount)
+		}
+	}
+
+	for _, invalid := range []string{"", "C", "X00000150", "00000150", "C0000015A"} {
+		if _, err := ParseSignedAmount(invalid); err == nil {
+			t.Errorf("ParseSignedAmount(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestSignedAmountFields(t *testing.T) {
+	msg := NewISO()
+	msg.SetMTI("0200")
+
+	if err := msg.SetSignedAmount(28, -150); err != nil {
+		t.Fatalf("SetSignedAmount() error = %v", err)
+	}
+	if err := msg.SetSignedAmount(97, 123456); err != nil {
+		t.Fatalf("SetSignedAmount() error = %v", err)
+	}
+	if msg.Fields[28] != "D00000150" || msg.Fields[97] != "C0000000000123456" {
+		t.Errorf("Unexpected x+n values %s, %s", msg.Fields[28], msg.Fields[97])
+	}
+
+	if err := msg.SetSignedAmount(4, 100); err == nil {
+		t.Errorf("Expected error setting a signed amount on an n field")
+	}
+	if err := msg.SetSignedAmount(29, 123456789); err == nil {
+		t.Errorf("Expected error for amount too large for field 29")
+	}
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if fee, err := parsed.SignedAmount(28); err != nil || fee != -150 {
+		t.Errorf("Field 28: expected -150, got %d (error = %v)", fee, err)
+	}
+
+	msg.AddField(30, "X00000000")
+	if _, err := msg.Build(); err == nil {
+		t.Errorf("Expected error building an x+n field without sign")
+	}
+
+	long := NewISO().SetMTI("0200").AddField(28, "C000000001234")
+	if _, err := long.Build(); err == nil {
+		t.Errorf("Expected error building an x+n value longer than the field")
+	}
+
+	msg.AddField(30, "D15")
+	if _, err := msg.Build(); err != nil {
+		t.Errorf("Expected short x+n value to be padded, got %v", err)
+	}
+}
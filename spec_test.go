@@ -0,0 +1,57 @@
+package iso8583
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadSpec(t *testing.T) {
+	raw := `{
+		"name": "Test",
+		"elements": {
+			"2": {"contentType": "n", "label": "PAN", "lenType": "LLVAR", "maxLen": 19},
+			"3": {"contentType": "n", "label": "Processing code", "lenType": "Fixed", "maxLen": 6}
+		},
+		"echoFields": [2, 3]
+	}`
+
+	spec, err := LoadSpec(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+
+	elem, ok := spec.Element(2)
+	if !ok || elem.LenType != LLVAR || elem.MaxLen != 19 {
+		t.Errorf("Element 2: unexpected definition %+v", elem)
+	}
+
+	msg := NewISOWithSpec(spec)
+	msg.SetMTI("0100").AddField(2, "4000001234567890").AddField(3, "000000")
+	iso, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if expected := "01006000000000000000164000001234567890000000"; iso != expected {
+		t.Errorf("Expected ISO message = %s, got %s", expected, iso)
+	}
+
+	if _, err := LoadSpec(strings.NewReader(`{"elements": {"2": {"lenType": "LVAR", "maxLen": 2}}}`)); err == nil {
+		t.Errorf("Expected error for invalid length type")
+	}
+}
+
+func TestSpecWithPOSDataCode(t *testing.T) {
+	elem, ok := SpecWithPOSDataCode.Element(22)
+	if !ok || elem.ContentType != "an" || elem.MaxLen != 12 {
+		t.Errorf("Unexpected field 22 in SpecWithPOSDataCode: %+v", elem)
+	}
+
+	if elem, _ := DefaultSpec.Element(22); elem.MaxLen != 3 {
+		t.Errorf("Expected the default spec field 22 to be unchanged, got %+v", elem)
+	}
+
+	if err := SpecWithPOSDataCode.SetCodec(48, &TLVCodec{TagLen: 2, LenLen: 2}); err == nil {
+		t.Errorf("Expected error attaching a codec to SpecWithPOSDataCode")
+	}
+}
@@ -0,0 +1,272 @@
+package iso8583
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TLV is a BER-TLV data object. Tag is the uppercase hexadecimal tag,
+// and constructed objects carry their nested objects in Children
+// instead of Value.
+type TLV struct {
+	Tag      string
+	Value    []byte
+	Children []TLV
+}
+
+// Constructed reports whether the tag denotes a constructed data object.
+func (t TLV) Constructed() (bool, error) {
+	if len(t.Tag) < 2 {
+		return false, fmt.Errorf("invalid tag %q", t.Tag)
+	}
+	first, err := hex.DecodeString(t.Tag[:2])
+	if err != nil {
+		return false, fmt.Errorf("invalid tag %q", t.Tag)
+	}
+	return first[0]&0x20 != 0, nil
+}
+
+// DecodeBERTLV decodes a sequence of BER-TLV data objects, supporting
+// multi-byte tags, long form lengths and constructed objects.
+func DecodeBERTLV(data []byte) ([]TLV, error) {
+	var tlvs []TLV
+
+	for len(data) > 0 {
+		// Skip padding between data objects
+		if data[0] == 0x00 {
+			data = data[1:]
+			continue
+		}
+
+		tagLen := 1
+		if data[0]&0x1F == 0x1F {
+			for {
+				if tagLen >= len(data) {
+					return nil, fmt.Errorf("truncated tag %X", data)
+				}
+				tagLen++
+				if data[tagLen-1]&0x80 == 0 {
+					break
+				}
+			}
+		}
+		tag := strings.ToUpper(hex.EncodeToString(data[:tagLen]))
+		data = data[tagLen:]
+
+		if len(data) == 0 {
+			return nil, fmt.Errorf("missing length for tag %s", tag)
+		}
+
+		length := int(data[0])
+		data = data[1:]
+		if length&0x80 != 0 {
+			numBytes := length & 0x7F
+			if numBytes == 0 || numBytes > 4 || numBytes > len(data) {
+				return nil, fmt.Errorf("invalid length for tag %s", tag)
+			}
+			length = 0
+			for _, b := range data[:numBytes] {
+				length = length<<8 | int(b)
+			}
+			data = data[numBytes:]
+		}
+
+		if length > len(data) {
+			return nil, fmt.Errorf("not enough data for tag %s: expected %d bytes, got %d", tag, length, len(data))
+		}
+
+		tlv := TLV{Tag: tag, Value: data[:length]}
+		data = data[length:]
+
+		if constructed, _ := tlv.Constructed(); constructed {
+			children, err := DecodeBERTLV(tlv.Value)
+			if err != nil {
+				return nil, fmt.Errorf("tag %s: %v", tag, err)
+			}
+			tlv.Value = nil
+			tlv.Children = children
+		}
+
+		tlvs = append(tlvs, tlv)
+	}
+
+	return tlvs, nil
+}
+
+// EncodeBERTLV encodes a sequence of BER-TLV data objects.
+func EncodeBERTLV(tlvs []TLV) ([]byte, error) {
+	var out []byte
+
+	for _, tlv := range tlvs {
+		tag, err := hex.DecodeString(tlv.Tag)
+		if err != nil || !validTag(tag) {
+			return nil, fmt.Errorf("invalid tag %q", tlv.Tag)
+		}
+
+		constructed, err := tlv.Constructed()
+		if err != nil {
+			return nil, err
+		}
+
+		value := tlv.Value
+		if constructed && len(tlv.Children) > 0 {
+			value, err = EncodeBERTLV(tlv.Children)
+			if err != nil {
+				return nil, fmt.Errorf("tag %s: %v", tlv.Tag, err)
+			}
+		}
+
+		out = append(out, tag...)
+		out = append(out, berLength(len(value))...)
+		out = append(out, value...)
+	}
+
+	return out, nil
+}
+
+// validTag reports whether tag is a well formed BER tag.
+func validTag(tag []byte) bool {
+	if len(tag) == 0 {
+		return false
+	}
+	if tag[0]&0x1F != 0x1F {
+		return len(tag) == 1
+	}
+	for i, b := range tag[1:] {
+		last := i == len(tag)-2
+		if (b&0x80 == 0) != last {
+			return false
+		}
+	}
+	return len(tag) > 1
+}
+
+// berLength encodes a length in short or long form.
+func berLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+
+	var digits []byte
+	for n := length; n > 0; n >>= 8 {
+		digits = append([]byte{byte(n)}, digits...)
+	}
+
+	return append([]byte{0x80 | byte(len(digits))}, digits...)
+}
+
+// BERTLVCodec decomposes hex encoded BER-TLV data, such as the EMV chip
+// data of field 55, into subfields keyed by tag with hex encoded values.
+// Objects nested in constructed tags are keyed by the path of tags, e.g.
+// "55.70.57". Tags are encoded in ascending order.
+type BERTLVCodec struct {
+	// Tags describes the known tags, used for labels.
+	Tags map[string]Element
+}
+
+// Decode decodes hex encoded BER-TLV data into subfields.
+func (c *BERTLVCodec) Decode(value string) (map[string]string, error) {
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex data: %v", err)
+	}
+
+	tlvs, err := DecodeBERTLV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	subfields := make(map[string]string)
+	flattenTLV("", tlvs, subfields)
+
+	return subfields, nil
+}
+
+// flattenTLV stores the data objects in subfields keyed by tag path.
+func flattenTLV(prefix string, tlvs []TLV, subfields map[string]string) {
+	for _, tlv := range tlvs {
+		key := prefix + tlv.Tag
+
+		if len(tlv.Children) > 0 {
+			flattenTLV(key+".", tlv.Children, subfields)
+			encoded, _ := EncodeBERTLV(tlv.Children)
+			subfields[key] = strings.ToUpper(hex.EncodeToString(encoded))
+			continue
+		}
+
+		subfields[key] = strings.ToUpper(hex.EncodeToString(tlv.Value))
+	}
+}
+
+// Encode encodes subfields keyed by tag into hex encoded BER-TLV data.
+func (c *BERTLVCodec) Encode(subfields map[string]string) (string, error) {
+	tlvs, err := nestTLV("", subfields)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := EncodeBERTLV(tlvs)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToUpper(hex.EncodeToString(data)), nil
+}
+
+// nestTLV builds the data objects below prefix from subfields keyed by
+// tag path. Constructed objects with nested subfields are built from
+// them, otherwise from their own value.
+func nestTLV(prefix string, subfields map[string]string) ([]TLV, error) {
+	tags := make(map[string]bool)
+	for key := range subfields {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		tag, _, _ := strings.Cut(key[len(prefix):], ".")
+		tags[tag] = true
+	}
+
+	sorted := make([]string, 0, len(tags))
+	for tag := range tags {
+		sorted = append(sorted, tag)
+	}
+	sort.Strings(sorted)
+
+	var tlvs []TLV
+	for _, tag := range sorted {
+		tlv := TLV{Tag: strings.ToUpper(tag)}
+		key := prefix + tag
+
+		if hasNestedSubfields(subfields, key) {
+			children, err := nestTLV(key+".", subfields)
+			if err != nil {
+				return nil, err
+			}
+			tlv.Children = children
+		} else {
+			value, err := hex.DecodeString(subfields[key])
+			if err != nil {
+				return nil, fmt.Errorf("tag %s: invalid hex value: %v", tag, err)
+			}
+			tlv.Value = value
+		}
+
+		tlvs = append(tlvs, tlv)
+	}
+
+	return tlvs, nil
+}
+
+// Element returns the definition of a tag, given by its tag path. Tags missing from the
+// dictionary are reported as unknown binary data.
+func (c *BERTLVCodec) Element(tag string) (Element, bool) {
+	// Nested tags are looked up by their own tag
+	tag = tag[strings.LastIndex(tag, ".")+1:]
+
+	if elem, ok := c.Tags[strings.ToUpper(tag)]; ok {
+		return elem, true
+	}
+	return Element{ContentType: "b", Label: "Unknown tag " + strings.ToUpper(tag), LenType: LLVAR}, true
+}
@@ -0,0 +1,157 @@
+package iso8583
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBERTLV(t *testing.T) {
+	withoutPadding := "9F2608A1B2C3D4E5F60718" + "9F270180" + "950500000000" + "80" + "7008" + "5F2A020986" + "9C0100"
+	data, _ := hex.DecodeString(strings.Replace(withoutPadding, "7008", "007008", 1))
+
+	tlvs, err := DecodeBERTLV(data)
+	if err != nil {
+		t.Fatalf("DecodeBERTLV() error = %v", err)
+	}
+
+	if len(tlvs) != 4 {
+		t.Fatalf("Expected 4 data objects, got %d", len(tlvs))
+	}
+
+	if tlvs[0].Tag != "9F26" || hex.EncodeToString(tlvs[0].Value) != "a1b2c3d4e5f60718" {
+		t.Errorf("Unexpected first data object %+v", tlvs[0])
+	}
+
+	if constructed, err := tlvs[3].Constructed(); err != nil || !constructed || len(tlvs[3].Children) != 2 || tlvs[3].Children[1].Tag != "9C" {
+		t.Errorf("Unexpected constructed data object %+v", tlvs[3])
+	}
+
+	encoded, err := EncodeBERTLV(tlvs)
+	if err != nil {
+		t.Fatalf("EncodeBERTLV() error = %v", err)
+	}
+
+	if hex.EncodeToString(encoded) != strings.ToLower(withoutPadding) {
+		t.Errorf("Expected %s, got %X", withoutPadding, encoded)
+	}
+}
+
+func TestBERTLVLongLength(t *testing.T) {
+	value := bytes.Repeat([]byte{0xAB}, 300)
+
+	encoded, err := EncodeBERTLV([]TLV{{Tag: "9F10", Value: value}})
+	if err != nil {
+		t.Fatalf("EncodeBERTLV() error = %v", err)
+	}
+
+	if !bytes.Equal(encoded[:5], []byte{0x9F, 0x10, 0x82, 0x01, 0x2C}) {
+		t.Errorf("Expected long form length, got %X", encoded[:5])
+	}
+
+	tlvs, err := DecodeBERTLV(encoded)
+	if err != nil {
+		t.Fatalf("DecodeBERTLV() error = %v", err)
+	}
+	if len(tlvs) != 1 || !bytes.Equal(tlvs[0].Value, value) {
+		t.Errorf("Unexpected round trip result")
+	}
+}
+
+func TestBERTLVErrors(t *testing.T) {
+	for _, raw := range []string{"9F", "9F26", "9F2608A1B2", "9F2685FFFFFFFFFF"} {
+		data, _ := hex.DecodeString(raw)
+		if _, err := DecodeBERTLV(data); err == nil {
+			t.Errorf("DecodeBERTLV(%s): expected error", raw)
+		}
+	}
+
+	for _, tag := range []string{"9F", "1F80", "XX", "9F2601"} {
+		if _, err := EncodeBERTLV([]TLV{{Tag: tag}}); err == nil {
+			t.Errorf("EncodeBERTLV(%s): expected error", tag)
+		}
+	}
+}
+
+func TestTLVConstructedInvalidTag(t *testing.T) {
+	for _, tag := range []string{"", "7", "XX"} {
+		if _, err := (TLV{Tag: tag}).Constructed(); err == nil {
+			t.Errorf("Constructed(%q): expected error", tag)
+		}
+	}
+}
+
+func TestField55EMVTags(t *testing.T) {
+	spec := DefaultSpec.Clone()
+	if err := spec.SetCodec(55, &BERTLVCodec{Tags: EMVTags}); err != nil {
+		t.Fatalf("SetCodec() error = %v", err)
+	}
+
+	msg := NewISOWithSpec(spec)
+	msg.SetMTI("0100")
+	msg.AddField(3, "000000")
+	msg.SetSubfield("55.9F26", "A1B2C3D4E5F60718")
+	msg.SetSubfield("55.9F27", "80")
+	msg.SetSubfield("55.95", "0000000080")
+	msg.SetSubfield("55.9F36", "001C")
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	expected := "950500000000809F2608A1B2C3D4E5F607189F2701809F3602001C"
+	if !strings.HasSuffix(raw, "054"+expected) {
+		t.Errorf("Expected field 55 = %s, got %s", expected, raw)
+	}
+
+	parsed, err := NewParserWithSpec(spec).Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	msg.SetSubfield("55.70.9C", "00")
+	if raw, err = msg.Build(); err != nil || !strings.Contains(raw, "70039C0100") {
+		t.Errorf("Expected constructed tag 70 in %s (error = %v)", raw, err)
+	}
+
+	if value, ok := parsed.Subfield("55.9F36"); !ok || value != "001C" {
+		t.Errorf("Subfield 55.9F36: expected 001C, got %q", value)
+	}
+
+	elem, ok := parsed.Spec().SubElement("55.9F26")
+	if !ok || elem.Label != "Application Cryptogram" {
+		t.Errorf("Subelement 55.9F26: unexpected definition %+v", elem)
+	}
+
+	invalid, err := NewParserWithSpec(spec).Parse("0100" + "0000000000000200" + "003ZZZ")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if invalid.Fields[55] != "ZZZ" {
+		t.Errorf("Field 55: expected ZZZ, got %s", invalid.Fields[55])
+	}
+	if len(invalid.Subfields) != 0 {
+		t.Errorf("Expected no subfields for invalid field 55 data, got %v", invalid.Subfields)
+	}
+}
+
+func TestField55DefaultSpecRaw(t *testing.T) {
+	msg := NewISO()
+	msg.SetMTI("0100")
+	msg.AddField(55, "PROPRIETARY CHIP DATA")
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parsed.Fields[55] != "PROPRIETARY CHIP DATA" {
+		t.Errorf("Field 55: expected PROPRIETARY CHIP DATA, got %s", parsed.Fields[55])
+	}
+}
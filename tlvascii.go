@@ -0,0 +1,222 @@
+package iso8583
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LengthEncoding represents how the length of a private TLV subfield is written.
+type LengthEncoding int
+
+// List of length encodings.
+const (
+	// DecimalLength lengths are zero padded decimal digits.
+	DecimalLength LengthEncoding = iota
+	// HexLength lengths are zero padded uppercase hexadecimal digits.
+	HexLength
+)
+
+// UnknownTagPolicy represents how a TLVCodec handles tags missing from its dictionary.
+type UnknownTagPolicy int
+
+// List of unknown tag policies.
+const (
+	// KeepUnknownTags decodes and encodes unknown tags like known ones.
+	KeepUnknownTags UnknownTagPolicy = iota
+	// DropUnknownTags silently ignores unknown tags.
+	DropUnknownTags
+	// RejectUnknownTags fails on unknown tags.
+	RejectUnknownTags
+)
+
+// TLVCodec decomposes private data made of ASCII tag-length-value
+// sequences, as found in fields 48, 62 and 63, into subfields keyed by
+// tag. For example, with 2 character tags and 3 digit lengths the value
+// "01003ABC02002XY" holds tag 01 = "ABC" and tag 02 = "XY".
+type TLVCodec struct {
+	TagLen      int
+	LenLen      int
+	LenEncoding LengthEncoding
+
+	// Tags describes the known tags. Values longer than the MaxLen of
+	// their tag are rejected.
+	Tags map[string]Element
+
+	// Order lists the tags in the order they are encoded. Tags missing
+	// from it are encoded after them in ascending order.
+	Order []string
+
+	// StrictOrder rejects decoded tags that do not follow Order.
+	StrictOrder bool
+
+	Unknown UnknownTagPolicy
+}
+
+// Decode splits a TLV sequence into subfields keyed by tag.
+func (c *TLVCodec) Decode(value string) (map[string]string, error) {
+	if c.TagLen <= 0 || c.LenLen <= 0 {
+		return nil, fmt.Errorf("tag and length widths are required")
+	}
+
+	subfields := make(map[string]string)
+	lastRank := -1
+
+	for remaining := value; remaining != ""; {
+		if len(remaining) < c.TagLen+c.LenLen {
+			return nil, fmt.Errorf("truncated tag at %q", remaining)
+		}
+
+		tag := remaining[:c.TagLen]
+
+		length, err := c.parseLength(remaining[c.TagLen : c.TagLen+c.LenLen])
+		if err != nil {
+			return nil, fmt.Errorf("tag %s: %v", tag, err)
+		}
+
+		remaining = remaining[c.TagLen+c.LenLen:]
+		if length > len(remaining) {
+			return nil, fmt.Errorf("tag %s: not enough data: expected %d characters, got %d", tag, length, len(remaining))
+		}
+
+		tagValue := remaining[:length]
+		remaining = remaining[length:]
+
+		if c.StrictOrder {
+			rank := c.rank(tag)
+			if rank < lastRank {
+				return nil, fmt.Errorf("tag %s is out of order", tag)
+			}
+			lastRank = rank
+		}
+
+		if _, known := c.Tags[tag]; !known {
+			switch c.Unknown {
+			case DropUnknownTags:
+				continue
+			case RejectUnknownTags:
+				return nil, fmt.Errorf("unknown tag %s", tag)
+			}
+		}
+
+		if _, dup := subfields[tag]; dup {
+			return nil, fmt.Errorf("duplicate tag %s", tag)
+		}
+		subfields[tag] = tagValue
+	}
+
+	return subfields, nil
+}
+
+// Encode composes a TLV sequence from subfields keyed by tag.
+func (c *TLVCodec) Encode(subfields map[string]string) (string, error) {
+	if c.TagLen <= 0 || c.LenLen <= 0 {
+		return "", fmt.Errorf("tag and length widths are required")
+	}
+
+	tags := make([]string, 0, len(subfields))
+	for tag := range subfields {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		ri, rj := c.rank(tags[i]), c.rank(tags[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return tags[i] < tags[j]
+	})
+
+	maxLen := c.maxLength()
+
+	var out strings.Builder
+	for _, tag := range tags {
+		value := subfields[tag]
+
+		if len(tag) != c.TagLen {
+			return "", fmt.Errorf("tag %q must have %d characters", tag, c.TagLen)
+		}
+
+		elem, known := c.Tags[tag]
+		if !known {
+			switch c.Unknown {
+			case DropUnknownTags:
+				continue
+			case RejectUnknownTags:
+				return "", fmt.Errorf("unknown tag %s", tag)
+			}
+		}
+
+		if known && elem.MaxLen > 0 && len(value) > elem.MaxLen {
+			return "", fmt.Errorf("tag %s: length %d exceeds maximum %d", tag, len(value), elem.MaxLen)
+		}
+		if len(value) > maxLen {
+			return "", fmt.Errorf("tag %s: length %d does not fit in %d digits", tag, len(value), c.LenLen)
+		}
+
+		out.WriteString(tag)
+		out.WriteString(c.formatLength(len(value)))
+		out.WriteString(value)
+	}
+
+	return out.String(), nil
+}
+
+// Element returns the definition of a tag.
+func (c *TLVCodec) Element(tag string) (Element, bool) {
+	if elem, ok := c.Tags[tag]; ok {
+		return elem, true
+	}
+	if c.Unknown == RejectUnknownTags || len(tag) != c.TagLen {
+		return Element{}, false
+	}
+	return Element{ContentType: "ans", Label: "Unknown tag " + tag, LenType: LLLVAR, MaxLen: c.maxLength()}, true
+}
+
+// rank returns the position of a tag in Order, or len(Order) if missing.
+func (c *TLVCodec) rank(tag string) int {
+	for i, ordered := range c.Order {
+		if ordered == tag {
+			return i
+		}
+	}
+	return len(c.Order)
+}
+
+// parseLength decodes a length indicator.
+func (c *TLVCodec) parseLength(raw string) (int, error) {
+	base := 10
+	if c.LenEncoding == HexLength {
+		base = 16
+	}
+
+	length, err := strconv.ParseUint(raw, base, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length %q", raw)
+	}
+
+	return int(length), nil
+}
+
+// formatLength encodes a length indicator.
+func (c *TLVCodec) formatLength(length int) string {
+	if c.LenEncoding == HexLength {
+		return fmt.Sprintf("%0*X", c.LenLen, length)
+	}
+	return fmt.Sprintf("%0*d", c.LenLen, length)
+}
+
+// maxLength returns the largest length the length indicator can hold.
+func (c *TLVCodec) maxLength() int {
+	base := 10
+	if c.LenEncoding == HexLength {
+		base = 16
+	}
+
+	max := 1
+	for i := 0; i < c.LenLen; i++ {
+		max *= base
+	}
+
+	return max - 1
+}
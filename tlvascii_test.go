@@ -0,0 +1,121 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestTLVCodec(t *testing.T) {
+	codec := &TLVCodec{TagLen: 2, LenLen: 3}
+
+	subfields, err := codec.Decode("01003ABC02002XY")
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if subfields["01"] != "ABC" || subfields["02"] != "XY" || len(subfields) != 2 {
+		t.Errorf("Unexpected subfields %v", subfields)
+	}
+
+	value, err := codec.Encode(map[string]string{"02": "XY", "01": "ABC"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if value != "01003ABC02002XY" {
+		t.Errorf("Expected 01003ABC02002XY, got %s", value)
+	}
+
+	for _, invalid := range []string{"01", "01003AB", "010X3ABC", "01001A01001B"} {
+		if _, err := codec.Decode(invalid); err == nil {
+			t.Errorf("Decode(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestTLVCodecOptions(t *testing.T) {
+	codec := &TLVCodec{
+		TagLen:      3,
+		LenLen:      2,
+		LenEncoding: HexLength,
+		Tags: map[string]Element{
+			"ZZZ": {ContentType: "an", Label: "Terminal type", MaxLen: 4},
+			"AAA": {ContentType: "an", Label: "Reference", MaxLen: 20},
+		},
+		Order:       []string{"ZZZ", "AAA"},
+		StrictOrder: true,
+	}
+
+	value, err := codec.Encode(map[string]string{"AAA": "0123456789ABCDEF", "ZZZ": "POS"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if value != "ZZZ03POSAAA100123456789ABCDEF" {
+		t.Errorf("Expected declared order with hex lengths, got %s", value)
+	}
+
+	if _, err := codec.Decode("AAA01XZZZ03POS"); err == nil {
+		t.Errorf("Expected error for tags out of order")
+	}
+
+	if _, err := codec.Encode(map[string]string{"ZZZ": "TOO LONG"}); err == nil {
+		t.Errorf("Expected error for value longer than its tag")
+	}
+
+	codec.Unknown = DropUnknownTags
+	subfields, err := codec.Decode("ZZZ03POSQQQ01X")
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if _, ok := subfields["QQQ"]; ok {
+		t.Errorf("Expected unknown tag to be dropped")
+	}
+
+	codec.Unknown = RejectUnknownTags
+	if _, err := codec.Decode("ZZZ03POSQQQ01X"); err == nil {
+		t.Errorf("Expected error for unknown tag")
+	}
+	if _, ok := codec.Element("QQQ"); ok {
+		t.Errorf("Expected unknown tag to be undefined")
+	}
+}
+
+func TestSpecSetCodec(t *testing.T) {
+	spec := DefaultSpec.Clone()
+	if err := spec.SetCodec(48, &TLVCodec{TagLen: 2, LenLen: 2}); err != nil {
+		t.Fatalf("SetCodec() error = %v", err)
+	}
+
+	if err := spec.SetCodec(41, &TLVCodec{TagLen: 2, LenLen: 2}); err == nil {
+		t.Errorf("Expected error attaching a codec to a fixed-length field")
+	}
+
+	if err := DefaultSpec.SetCodec(48, &TLVCodec{TagLen: 2, LenLen: 2}); err == nil {
+		t.Errorf("Expected error attaching a codec to the default spec")
+	}
+
+	if DefaultSpec.Elements[48].Codec != nil {
+		t.Errorf("Expected the default spec to be unchanged")
+	}
+
+	msg := NewISOWithSpec(spec)
+	msg.SetMTI("0100")
+	msg.AddField(3, "000000")
+	msg.SetSubfield("48.01", "ABC")
+	msg.SetSubfield("48.02", "XY")
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParserWithSpec(spec).Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if parsed.Fields[48] != "0103ABC0202XY" {
+		t.Errorf("Field 48: expected 0103ABC0202XY, got %s", parsed.Fields[48])
+	}
+	if value, _ := parsed.Subfield("48.02"); value != "XY" {
+		t.Errorf("Subfield 48.02: expected XY, got %q", value)
+	}
+}
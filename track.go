@@ -0,0 +1,239 @@
+package iso8583
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Track2 is the content of field 35, the track 2 data of a card:
+// PAN=YYMM + service code + discretionary data.
+type Track2 struct {
+	PAN           string
+	Expiry        string // YYMM
+	ServiceCode   string
+	Discretionary string
+}
+
+// ParseTrack2 parses track 2 data. The PAN is separated from the rest
+// of the data by '=' or 'D', and start/end sentinels are ignored.
+func ParseTrack2(value string) (Track2, error) {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, ";"), "?")
+
+	sep := strings.IndexAny(value, "=D")
+	if sep < 0 {
+		return Track2{}, fmt.Errorf("track 2 data has no separator")
+	}
+
+	t := Track2{PAN: value[:sep]}
+	rest := value[sep+1:]
+
+	if len(rest) < 4 {
+		return Track2{}, fmt.Errorf("track 2 data has no expiry date")
+	}
+	t.Expiry, rest = rest[:4], rest[4:]
+
+	if len(rest) >= 3 {
+		t.ServiceCode, t.Discretionary = rest[:3], rest[3:]
+	} else if rest != "" {
+		return Track2{}, fmt.Errorf("track 2 data has an incomplete service code")
+	}
+
+	return t, t.Validate()
+}
+
+// String formats the track 2 data with '=' as separator.
+func (t Track2) String() string {
+	return t.PAN + "=" + t.Expiry + t.ServiceCode + t.Discretionary
+}
+
+// Validate checks the components. The total length depends on the spec
+// and is checked when the track is set on a builder.
+func (t Track2) Validate() error {
+	if len(t.PAN) < 12 || len(t.PAN) > 19 || !isNumeric(t.PAN) {
+		return fmt.Errorf("invalid track 2 PAN")
+	}
+	if len(t.Expiry) != 4 || !isNumeric(t.Expiry) {
+		return fmt.Errorf("invalid track 2 expiry date %q", t.Expiry)
+	}
+	if t.ServiceCode != "" && (len(t.ServiceCode) != 3 || !isNumeric(t.ServiceCode)) {
+		return fmt.Errorf("invalid track 2 service code %q", t.ServiceCode)
+	}
+	if !isNumeric(t.Discretionary) {
+		return fmt.Errorf("invalid track 2 discretionary data")
+	}
+	return nil
+}
+
+// Track1 is the content of field 45, the track 1 format B data of a
+// card: B + PAN ^ name ^ YYMM + service code + discretionary data.
+type Track1 struct {
+	PAN           string
+	Name          string
+	Expiry        string // YYMM
+	ServiceCode   string
+	Discretionary string
+}
+
+// ParseTrack1 parses track 1 format B data. Start/end sentinels are ignored.
+func ParseTrack1(value string) (Track1, error) {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "%"), "?")
+
+	if !strings.HasPrefix(value, "B") {
+		return Track1{}, fmt.Errorf("track 1 data is not format B")
+	}
+
+	parts := strings.SplitN(value[1:], "^", 3)
+	if len(parts) != 3 {
+		return Track1{}, fmt.Errorf("track 1 data must have 3 fields separated by '^'")
+	}
+
+	t := Track1{PAN: parts[0], Name: parts[1]}
+	rest := parts[2]
+
+	if len(rest) < 4 {
+		return Track1{}, fmt.Errorf("track 1 data has no expiry date")
+	}
+	t.Expiry, rest = rest[:4], rest[4:]
+
+	if len(rest) >= 3 {
+		t.ServiceCode, t.Discretionary = rest[:3], rest[3:]
+	} else if rest != "" {
+		return Track1{}, fmt.Errorf("track 1 data has an incomplete service code")
+	}
+
+	return t, t.Validate()
+}
+
+// String formats the track 1 format B data.
+func (t Track1) String() string {
+	return "B" + t.PAN + "^" + t.Name + "^" + t.Expiry + t.ServiceCode + t.Discretionary
+}
+
+// Validate checks the components. The total length depends on the spec
+// and is checked when the track is set on a builder.
+func (t Track1) Validate() error {
+	if len(t.PAN) < 12 || len(t.PAN) > 19 || !isNumeric(t.PAN) {
+		return fmt.Errorf("invalid track 1 PAN")
+	}
+	if len(t.Name) < 2 || len(t.Name) > 26 || strings.Contains(t.Name, "^") {
+		return fmt.Errorf("invalid track 1 name %q", t.Name)
+	}
+	if len(t.Expiry) != 4 || !isNumeric(t.Expiry) {
+		return fmt.Errorf("invalid track 1 expiry date %q", t.Expiry)
+	}
+	if t.ServiceCode != "" && (len(t.ServiceCode) != 3 || !isNumeric(t.ServiceCode)) {
+		return fmt.Errorf("invalid track 1 service code %q", t.ServiceCode)
+	}
+	return nil
+}
+
+// checkTrackLength checks a track against the max length of its field
+// in the spec.
+func checkTrackLength(spec *Spec, fieldNum int, value string) error {
+	if elem, ok := spec.Element(fieldNum); ok && len(value) > elem.MaxLen {
+		return fmt.Errorf("track data length %d exceeds maximum %d of field %d", len(value), elem.MaxLen, fieldNum)
+	}
+	return nil
+}
+
+// SetTrack2 sets field 35 from track 2 components, also checking the
+// length against the builder's spec and the PAN and expiry date against
+// fields 2 and 14 when they are set.
+func (mb *MessageBuilder) SetTrack2(t Track2) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	if err := checkTrackLength(mb.Spec(), 35, t.String()); err != nil {
+		return err
+	}
+	if err := checkTrackFields(mb.Fields, "track 2", t.PAN, t.Expiry); err != nil {
+		return err
+	}
+	mb.AddField(35, t.String())
+	return nil
+}
+
+// SetTrack1 sets field 45 from track 1 components, also checking the
+// length against the builder's spec and the PAN and expiry date against
+// fields 2 and 14 when they are set.
+func (mb *MessageBuilder) SetTrack1(t Track1) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	if err := checkTrackLength(mb.Spec(), 45, t.String()); err != nil {
+		return err
+	}
+	if err := checkTrackFields(mb.Fields, "track 1", t.PAN, t.Expiry); err != nil {
+		return err
+	}
+	mb.AddField(45, t.String())
+	return nil
+}
+
+// Track2 returns the parsed field 35 of the message.
+func (m *Parser) Track2() (Track2, error) {
+	value, ok := m.Fields[35]
+	if !ok {
+		return Track2{}, fmt.Errorf("message has no track 2 data")
+	}
+	return ParseTrack2(value)
+}
+
+// Track1 returns the parsed field 45 of the message.
+func (m *Parser) Track1() (Track1, error) {
+	value, ok := m.Fields[45]
+	if !ok {
+		return Track1{}, fmt.Errorf("message has no track 1 data")
+	}
+	return ParseTrack1(value)
+}
+
+// CheckTrackData cross-checks the track data of a message against its
+// PAN (field 2) and expiry date (field 14), when present.
+func (m *Parser) CheckTrackData() error {
+	return checkTrackData(m.Fields)
+}
+
+// CheckTrackData cross-checks the track data set on the builder against
+// its PAN (field 2) and expiry date (field 14), when present.
+func (mb *MessageBuilder) CheckTrackData() error {
+	return checkTrackData(mb.Fields)
+}
+
+// checkTrackData cross-checks the track data fields against fields 2
+// and 14.
+func checkTrackData(fields map[int]string) error {
+	if value, ok := fields[35]; ok {
+		t2, err := ParseTrack2(value)
+		if err != nil {
+			return err
+		}
+		if err := checkTrackFields(fields, "track 2", t2.PAN, t2.Expiry); err != nil {
+			return err
+		}
+	}
+
+	if value, ok := fields[45]; ok {
+		t1, err := ParseTrack1(value)
+		if err != nil {
+			return err
+		}
+		if err := checkTrackFields(fields, "track 1", t1.PAN, t1.Expiry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkTrackFields checks the PAN and expiry date of a track against
+// fields 2 and 14, when present.
+func checkTrackFields(fields map[int]string, name, pan, expiry string) error {
+	if value, ok := fields[2]; ok && value != pan {
+		return fmt.Errorf("%s PAN does not match field 2", name)
+	}
+	if value, ok := fields[14]; ok && value != expiry {
+		return fmt.Errorf("%s expiry date %s does not match field 14 %s", name, expiry, value)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package iso8583
+
+import (
+	"testing"
+)
+
+func TestParseTrack2(t *testing.T) {
+	t2, err := ParseTrack2(";4000001234567890D2402101123456789?")
+	if err != nil {
+		t.Fatalf("ParseTrack2() error = %v", err)
+	}
+
+	expected := Track2{PAN: "4000001234567890", Expiry: "2402", ServiceCode: "101", Discretionary: "123456789"}
+	if t2 != expected {
+		t.Errorf("Expected %+v, got %+v", expected, t2)
+	}
+
+	if t2.String() != "4000001234567890=2402101123456789" {
+		t.Errorf("Unexpected formatting %s", t2.String())
+	}
+
+	for _, invalid := range []string{
+		"4000001234567890",
+		"4000001234567890=24",
+		"4000001234567890=240210",
+		"40000012=2402101",
+	} {
+		if _, err := ParseTrack2(invalid); err == nil {
+			t.Errorf("ParseTrack2(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestSetTrack2Length(t *testing.T) {
+	t2 := Track2{PAN: "4000001234567890123", Expiry: "2402", ServiceCode: "101", Discretionary: "1234567890123"}
+	if len(t2.String()) != 40 {
+		t.Fatalf("Expected 40 characters of track 2 data, got %d", len(t2.String()))
+	}
+
+	if err := NewISO().SetTrack2(t2); err == nil {
+		t.Errorf("Expected error for track 2 data over the default max length")
+	}
+
+	spec := DefaultSpec.Clone()
+	elem := spec.Elements[35]
+	elem.MaxLen = 40
+	spec.Elements[35] = elem
+
+	msg := NewISOWithSpec(spec)
+	msg.SetMTI("0200")
+	if err := msg.SetTrack2(t2); err != nil {
+		t.Fatalf("SetTrack2() error = %v", err)
+	}
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParserWithSpec(spec).Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	decoded, err := parsed.Track2()
+	if err != nil {
+		t.Fatalf("Track2() error = %v", err)
+	}
+	if decoded != t2 {
+		t.Errorf("Expected %+v, got %+v", t2, decoded)
+	}
+}
+
+func TestParseTrack1(t *testing.T) {
+	t1, err := ParseTrack1("%B4000001234567890^DOE/JOHN^2402101000000123000?")
+	if err != nil {
+		t.Fatalf("ParseTrack1() error = %v", err)
+	}
+
+	expected := Track1{PAN: "4000001234567890", Name: "DOE/JOHN", Expiry: "2402", ServiceCode: "101", Discretionary: "000000123000"}
+	if t1 != expected {
+		t.Errorf("Expected %+v, got %+v", expected, t1)
+	}
+
+	if t1.String() != "B4000001234567890^DOE/JOHN^2402101000000123000" {
+		t.Errorf("Unexpected formatting %s", t1.String())
+	}
+
+	for _, invalid := range []string{"4000001234567890^DOE/JOHN^2402101", "B4000001234567890^DOE/JOHN", "B4000001234567890^D^2402101"} {
+		if _, err := ParseTrack1(invalid); err == nil {
+			t.Errorf("ParseTrack1(%s): expected error", invalid)
+		}
+	}
+}
+
+func TestCheckTrackData(t *testing.T) {
+	msg := NewISO()
+	msg.SetMTI("0200")
+	msg.AddField(2, "4000001234567890")
+	msg.AddField(14, "2402")
+	if err := msg.SetTrack2(Track2{PAN: "4000001234567890", Expiry: "2402", ServiceCode: "101"}); err != nil {
+		t.Fatalf("SetTrack2() error = %v", err)
+	}
+	if err := msg.SetTrack1(Track1{PAN: "4000001234567890", Name: "DOE/JOHN", Expiry: "2402", ServiceCode: "101"}); err != nil {
+		t.Fatalf("SetTrack1() error = %v", err)
+	}
+
+	raw, err := msg.Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	parsed, err := NewParser().Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if err := parsed.CheckTrackData(); err != nil {
+		t.Errorf("CheckTrackData() error = %v", err)
+	}
+
+	parsed.Fields[14] = "2501"
+	if err := parsed.CheckTrackData(); err == nil {
+		t.Errorf("Expected error for mismatched expiry date")
+	}
+
+	parsed.Fields[14] = "2402"
+	parsed.Fields[2] = "4000001234567891"
+	if err := parsed.CheckTrackData(); err == nil {
+		t.Errorf("Expected error for mismatched PAN")
+	}
+}
+
+func TestBuilderCheckTrackData(t *testing.T) {
+	t2 := Track2{PAN: "4000001234567890", Expiry: "2402", ServiceCode: "101"}
+	t1 := Track1{PAN: "4000001234567890", Name: "DOE/JOHN", Expiry: "2402", ServiceCode: "101"}
+
+	msg := NewISO().SetMTI("0200").AddField(2, "4000001234567891")
+	if err := msg.SetTrack2(t2); err == nil {
+		t.Errorf("Expected error setting track 2 with a mismatched PAN")
+	}
+	if err := msg.SetTrack1(t1); err == nil {
+		t.Errorf("Expected error setting track 1 with a mismatched PAN")
+	}
+
+	msg = NewISO().SetMTI("0200").AddField(14, "2501")
+	if err := msg.SetTrack2(t2); err == nil {
+		t.Errorf("Expected error setting track 2 with a mismatched expiry date")
+	}
+
+	// Fields set after the track are checked with CheckTrackData
+	msg = NewISO().SetMTI("0200")
+	if err := msg.SetTrack2(t2); err != nil {
+		t.Fatalf("SetTrack2() error = %v", err)
+	}
+	if err := msg.CheckTrackData(); err != nil {
+		t.Errorf("CheckTrackData() error = %v", err)
+	}
+
+	msg.AddField(2, "4000001234567891")
+	if err := msg.CheckTrackData(); err == nil {
+		t.Errorf("Expected error for mismatched PAN")
+	}
+
+	msg.AddField(2, "4000001234567890").AddField(14, "2501")
+	if err := msg.CheckTrackData(); err == nil {
+		t.Errorf("Expected error for mismatched expiry date")
+	}
+}